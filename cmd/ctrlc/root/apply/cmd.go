@@ -10,6 +10,7 @@ import (
 	"github.com/ctrlplanedev/cli/internal/api"
 	"github.com/ctrlplanedev/cli/internal/api/providers"
 	"github.com/ctrlplanedev/cli/internal/api/resolver"
+	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
 	"github.com/fatih/color"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -21,6 +22,7 @@ func NewApplyCmd() *cobra.Command {
 	var filePatterns []string
 	var selectorRaw string
 	var providerName string
+	var onConflict string
 
 	cmd := &cobra.Command{
 		Use:   "apply",
@@ -38,16 +40,24 @@ func NewApplyCmd() *cobra.Command {
 
 			#  Apply URL
 			$ ctrlc apply -f https://example.com/config.yaml
+
+			# Leave resources owned by another provider untouched
+			$ ctrlc apply -f inventory.yaml --provider lab --on-conflict=skip
 		`),
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runApply(cmd.Context(), filePatterns, selectorRaw)
+			policy, err := resourceprovider.ParseConflictPolicy(onConflict)
+			if err != nil {
+				return err
+			}
+			return runApply(cmd.Context(), filePatterns, selectorRaw, policy)
 		},
 	}
 
 	cmd.Flags().StringArrayVarP(&filePatterns, "file", "f", nil, "Path or glob pattern to YAML files (can be specified multiple times, prefix with ! to exclude)")
 	cmd.Flags().StringVar(&selectorRaw, "selector", "", "Metadata selector in key=value format to apply to created resources")
 	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Name of the resource provider (if omitted, resources are upserted directly without a provider)")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", "take-over", "What to do with identifiers owned by another provider: skip, fail or take-over")
 	cmd.MarkFlagRequired("file")
 
 	viper.BindPFlag("provider", cmd.Flags().Lookup("provider"))
@@ -56,7 +66,7 @@ func NewApplyCmd() *cobra.Command {
 	return cmd
 }
 
-func runApply(ctx context.Context, filePatterns []string, selectorRaw string) error {
+func runApply(ctx context.Context, filePatterns []string, selectorRaw string, conflictPolicy resourceprovider.ConflictPolicy) error {
 	files, err := expandGlob(filePatterns)
 	if err != nil {
 		return err
@@ -129,7 +139,7 @@ func runApply(ctx context.Context, filePatterns []string, selectorRaw string) er
 	var results []providers.Result
 
 	if len(resourceSpecs) > 0 {
-		resourceResults := providers.BatchUpsertResources(applyCtx, resourceSpecs)
+		resourceResults := providers.BatchUpsertResources(applyCtx, resourceSpecs, conflictPolicy)
 		results = append(results, resourceResults...)
 	}
 
@@ -14,6 +14,7 @@ import (
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/ui"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/version"
+	"github.com/ctrlplanedev/cli/internal/plugin"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -64,5 +65,8 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(ui.NewUICmd())
 	cmd.AddCommand(version.NewVersionCmd())
 
+	// ctrlc-<name> executables on PATH become "ctrlc <name>".
+	plugin.AddCommands(cmd)
+
 	return cmd
 }
@@ -0,0 +1,293 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/ctrlplanedev/cli/internal/kinds"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
+	"github.com/ctrlplanedev/cli/internal/kubefilter"
+	"github.com/ctrlplanedev/cli/internal/kubeobject"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	applications    = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+	applicationSets = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applicationsets"}
+	configMaps      = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+type options struct {
+	providerName  string
+	clusterName   string
+	argoNamespace string
+	argoURL       string
+	filter        kubefilter.Filter
+}
+
+func NewSyncArgoCDCmd() *cobra.Command {
+	var opts options
+
+	cmd := &cobra.Command{
+		Use:   "argocd",
+		Short: "Sync Argo CD Applications and ApplicationSets into Ctrlplane",
+		Long: heredoc.Doc(`
+			Reads the Application and ApplicationSet resources of the Argo CD
+			instance in the current kubeconfig context and upserts them with
+			their source, destination, sync status and health as metadata.
+
+			Links point to the Argo CD UI at --argocd-url, or at the url
+			configured in the argocd-cm ConfigMap.
+		`),
+		Example: heredoc.Doc(`
+			$ ctrlc sync argocd
+			$ ctrlc sync argocd --argocd-url https://argocd.example.com --namespace argocd
+			$ ctrlc sync argocd --label-selector team=payments --interval 5m
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.providerName, "provider", "p", "", "Name of the resource provider (default is argocd-<cluster name>)")
+	cmd.Flags().StringVarP(&opts.clusterName, "cluster-name", "n", "", "The name of the cluster Argo CD runs in (default is the kubeconfig cluster)")
+	cmd.Flags().StringVar(&opts.argoNamespace, "argocd-namespace", "argocd", "Namespace Argo CD is installed in, used to read argocd-cm")
+	cmd.Flags().StringVar(&opts.argoURL, "argocd-url", "", "Base URL of the Argo CD UI for links (default is the url in argocd-cm)")
+	opts.filter.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *options) run(ctx context.Context) error {
+	return kubeobject.Sync(ctx, "argocd", o.providerName, o.clusterName,
+		func(ctx context.Context, client dynamic.Interface, clusterName string) ([]api.ResourceProviderResource, error) {
+			log.Info("Syncing Argo CD applications", "cluster", clusterName)
+			run := *o
+			run.clusterName = clusterName
+			return run.fetch(ctx, client)
+		}, ctrlp.WithRelationshipRules(relationshipRules...))
+}
+
+// fetch lists the Applications and ApplicationSets that pass the filter.
+// Clusters without the ApplicationSet CRD only yield Applications.
+func (o *options) fetch(ctx context.Context, client dynamic.Interface) ([]api.ResourceProviderResource, error) {
+	uiURL := o.uiURL(ctx, client)
+
+	apps, err := o.filter.List(ctx, client, applications)
+	if err != nil {
+		return nil, err
+	}
+	sets, err := o.filter.List(ctx, client, applicationSets)
+	if apierrors.IsNotFound(err) {
+		log.Debug("ApplicationSet CRD not installed, skipping ApplicationSets")
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Generated Applications are owned by their ApplicationSet
+	generated := map[string]int{}
+	for _, app := range apps {
+		if set := owningSet(app); set != "" {
+			generated[app.GetNamespace()+"/"+set]++
+		}
+	}
+
+	resources := make([]api.ResourceProviderResource, 0, len(apps)+len(sets))
+	for _, app := range apps {
+		resources = append(resources, applicationToResource(app, o.clusterName, uiURL))
+	}
+	for _, set := range sets {
+		resources = append(resources, applicationSetToResource(set, o.clusterName, generated[set.GetNamespace()+"/"+set.GetName()]))
+	}
+	log.Info("Found Argo CD resources", "applications", len(apps), "applicationSets", len(sets))
+	return resources, nil
+}
+
+// uiURL returns the base URL of the Argo CD UI, or "" when it is unknown.
+func (o *options) uiURL(ctx context.Context, client dynamic.Interface) string {
+	if o.argoURL != "" {
+		return strings.TrimSuffix(o.argoURL, "/")
+	}
+	cm, err := client.Resource(configMaps).Namespace(o.argoNamespace).Get(ctx, "argocd-cm", metav1.GetOptions{})
+	if err != nil {
+		log.Debug("Could not read argocd-cm, skipping links", "namespace", o.argoNamespace, "error", err)
+		return ""
+	}
+	url, _, _ := unstructured.NestedString(cm.Object, "data", "url")
+	return strings.TrimSuffix(url, "/")
+}
+
+func applicationToResource(app unstructured.Unstructured, clusterName, uiURL string) api.ResourceProviderResource {
+	identifier := fmt.Sprintf("%s/%s/%s", clusterName, app.GetNamespace(), app.GetName())
+	metadata := kubeobject.Metadata(app, "argocd", clusterName)
+
+	str := func(fields ...string) string {
+		value, _, _ := unstructured.NestedString(app.Object, fields...)
+		return value
+	}
+
+	// Multi-source applications report the first source in metadata
+	source, _, _ := unstructured.NestedMap(app.Object, "spec", "source")
+	sources, _, _ := unstructured.NestedSlice(app.Object, "spec", "sources")
+	if source == nil && len(sources) > 0 {
+		source, _ = sources[0].(map[string]any)
+	}
+	sourceStr := func(field string) string {
+		value, _ := source[field].(string)
+		return value
+	}
+	revision := str("status", "sync", "revision")
+	if revisions, _, _ := unstructured.NestedStringSlice(app.Object, "status", "sync", "revisions"); revision == "" && len(revisions) > 0 {
+		revision = revisions[0]
+	}
+
+	metadata["argocd/project"] = str("spec", "project")
+	metadata["argocd/source-repo"] = sourceStr("repoURL")
+	metadata["argocd/source-path"] = sourceStr("path")
+	metadata["argocd/source-chart"] = sourceStr("chart")
+	metadata["argocd/target-revision"] = sourceStr("targetRevision")
+	metadata["argocd/revision"] = revision
+	metadata["argocd/destination-server"] = str("spec", "destination", "server")
+	metadata["argocd/destination-host"] = kubeconfig.ServerHost(str("spec", "destination", "server"))
+	metadata["argocd/destination-name"] = str("spec", "destination", "name")
+	metadata["argocd/destination-namespace"] = str("spec", "destination", "namespace")
+	metadata["argocd/sync-status"] = str("status", "sync", "status")
+	metadata["argocd/health-status"] = str("status", "health", "status")
+	metadata["argocd/operation-phase"] = str("status", "operationState", "phase")
+	metadata["kubernetes/namespace"] = str("spec", "destination", "namespace")
+	if set := owningSet(app); set != "" {
+		metadata["argocd/application-set"] = set
+	}
+	metadata["ctrlplane/version"] = fmt.Sprintf("rev:%s sync:%s health:%s",
+		kubeobject.ShortRevision(revision), metadata["argocd/sync-status"], metadata["argocd/health-status"])
+	if uiURL != "" {
+		links, _ := json.Marshal(map[string]string{
+			"Argo CD": fmt.Sprintf("%s/applications/%s/%s", uiURL, app.GetNamespace(), app.GetName()),
+		})
+		metadata[kinds.CtrlplaneMetadataLinks] = string(links)
+	}
+	kubeobject.DropEmpty(metadata)
+
+	config := map[string]any{
+		"name":      app.GetName(),
+		"namespace": app.GetNamespace(),
+		"project":   metadata["argocd/project"],
+		"destination": map[string]any{
+			"server":    str("spec", "destination", "server"),
+			"name":      str("spec", "destination", "name"),
+			"namespace": str("spec", "destination", "namespace"),
+		},
+		"sync": map[string]any{
+			"status":   str("status", "sync", "status"),
+			"revision": revision,
+		},
+		"health": map[string]any{
+			"status": str("status", "health", "status"),
+		},
+	}
+	if source != nil {
+		config["source"] = source
+	}
+	if len(sources) > 0 {
+		config["sources"] = sources
+	}
+
+	return api.ResourceProviderResource{
+		Version:    "ctrlplane.dev/argocd/application/v1",
+		Kind:       "ArgoCDApplication",
+		Name:       identifier, // Use cluster/namespace/application for uniqueness
+		Identifier: identifier,
+		Config:     config,
+		Metadata:   metadata,
+	}
+}
+
+func applicationSetToResource(set unstructured.Unstructured, clusterName string, applicationCount int) api.ResourceProviderResource {
+	identifier := fmt.Sprintf("%s/%s/%s", clusterName, set.GetNamespace(), set.GetName())
+	metadata := kubeobject.Metadata(set, "argocd", clusterName)
+
+	// Each generator is an object with a single key naming its type
+	generators, _, _ := unstructured.NestedSlice(set.Object, "spec", "generators")
+	var generatorTypes []string
+	for _, generator := range generators {
+		if generator, ok := generator.(map[string]any); ok {
+			for kind := range generator {
+				generatorTypes = append(generatorTypes, kind)
+			}
+		}
+	}
+	slices.Sort(generatorTypes)
+
+	project, _, _ := unstructured.NestedString(set.Object, "spec", "template", "spec", "project")
+	metadata["argocd/project"] = project
+	metadata["argocd/generators"] = strings.Join(generatorTypes, ",")
+	metadata["argocd/application-count"] = strconv.Itoa(applicationCount)
+	conditions, _, _ := unstructured.NestedSlice(set.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if condition, ok := condition.(map[string]any); ok && condition["type"] == "ResourcesUpToDate" {
+			status, _ := condition["status"].(string)
+			metadata["argocd/resources-up-to-date"] = strings.ToLower(status)
+		}
+	}
+
+	return api.ResourceProviderResource{
+		Version:    "ctrlplane.dev/argocd/application-set/v1",
+		Kind:       "ArgoCDApplicationSet",
+		Name:       identifier,
+		Identifier: identifier,
+		Config: map[string]any{
+			"name":             set.GetName(),
+			"namespace":        set.GetNamespace(),
+			"project":          project,
+			"generators":       generatorTypes,
+			"applicationCount": applicationCount,
+		},
+		Metadata: metadata,
+	}
+}
+
+// owningSet returns the name of the ApplicationSet that generated app.
+func owningSet(app unstructured.Unstructured) string {
+	for _, owner := range app.GetOwnerReferences() {
+		if owner.Kind == "ApplicationSet" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// relationshipRules links generated Applications to their ApplicationSet,
+// and Applications to the cluster resource whose API server is their
+// destination. Both sides are compared as kubeconfig.ServerHost, since the
+// EKS, GKE and AKS syncs each format their endpoint differently.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "application-set",
+		Name:      "Argo CD Application Set",
+		Cel: `from.version == "ctrlplane.dev/argocd/application/v1" &&
+			to.version == "ctrlplane.dev/argocd/application-set/v1" &&
+			from.metadata["argocd/cluster"] == to.metadata["argocd/cluster"] &&
+			from.metadata["argocd/namespace"] == to.metadata["argocd/namespace"] &&
+			from.metadata["argocd/application-set"] == to.metadata["argocd/name"]`,
+	},
+	{
+		Reference: "destination",
+		Name:      "Argo CD Application Destination",
+		Cel: `from.version == "ctrlplane.dev/argocd/application/v1" &&
+			to.version == "ctrlplane.dev/kubernetes/cluster/v1" &&
+			from.metadata["argocd/destination-host"] == to.metadata["kubernetes/server-host"]`,
+	},
+}
@@ -0,0 +1,105 @@
+package argocd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/kubefilter"
+	"github.com/ctrlplanedev/cli/internal/kubeobject/kubeobjecttest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestFetch(t *testing.T) {
+	cm := kubeobjecttest.NewObject("v1", "ConfigMap", "argocd", "argocd-cm", map[string]any{
+		"data": map[string]any{"url": "https://argocd.example.com/"},
+	})
+	set := kubeobjecttest.NewObject("argoproj.io/v1alpha1", "ApplicationSet", "argocd", "guestbooks", map[string]any{
+		"spec": map[string]any{
+			"generators": []any{map[string]any{"list": map[string]any{}}, map[string]any{"git": map[string]any{}}},
+			"template":   map[string]any{"spec": map[string]any{"project": "demo"}},
+		},
+	})
+	guestbook := kubeobjecttest.NewObject("argoproj.io/v1alpha1", "Application", "argocd", "guestbook-prod", map[string]any{
+		"spec": map[string]any{
+			"project": "demo",
+			"source": map[string]any{
+				"repoURL":        "https://github.com/argoproj/argocd-example-apps",
+				"path":           "guestbook",
+				"targetRevision": "HEAD",
+			},
+			"destination": map[string]any{"server": "https://0123ABCD.gr7.us-west-2.eks.amazonaws.com:443", "namespace": "guestbook"},
+		},
+		"status": map[string]any{
+			"sync":   map[string]any{"status": "Synced", "revision": "4773b9f1f02b5b6c5e0b5b2f1e5dbb6e7a3d1c2b"},
+			"health": map[string]any{"status": "Healthy"},
+		},
+	})
+	guestbook.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ApplicationSet", Name: "guestbooks", UID: "set"}})
+	charts := kubeobjecttest.NewObject("argoproj.io/v1alpha1", "Application", "argocd", "monitoring", map[string]any{
+		"spec": map[string]any{
+			"sources": []any{
+				map[string]any{"repoURL": "https://prometheus-community.github.io/helm-charts", "chart": "kube-prometheus-stack", "targetRevision": "55.0.0"},
+				map[string]any{"repoURL": "https://github.com/example/values", "ref": "values"},
+			},
+			"destination": map[string]any{"name": "in-cluster", "namespace": "monitoring"},
+		},
+		"status": map[string]any{
+			"sync":   map[string]any{"status": "OutOfSync", "revisions": []any{"55.0.0", "abc"}},
+			"health": map[string]any{"status": "Degraded"},
+		},
+	})
+	skipped := kubeobjecttest.NewObject("argoproj.io/v1alpha1", "Application", "argocd", "scratch", map[string]any{})
+	skipped.SetAnnotations(map[string]string{kubefilter.OptOut: "false"})
+
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		applications:    "ApplicationList",
+		applicationSets: "ApplicationSetList",
+		configMaps:      "ConfigMapList",
+	}, cm, set, guestbook, charts, skipped)
+
+	o := options{clusterName: "mgmt", argoNamespace: "argocd"}
+	resources, err := o.fetch(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byIdentifier := map[string]api.ResourceProviderResource{}
+	for _, resource := range resources {
+		byIdentifier[resource.Identifier] = resource
+	}
+	if len(byIdentifier) != 3 {
+		t.Fatalf("expected two applications and one set, got %v", byIdentifier)
+	}
+
+	app := byIdentifier["mgmt/argocd/guestbook-prod"].Metadata
+	want := map[string]string{
+		"argocd/source-repo":           "https://github.com/argoproj/argocd-example-apps",
+		"argocd/target-revision":       "HEAD",
+		"argocd/revision":              "4773b9f1f02b5b6c5e0b5b2f1e5dbb6e7a3d1c2b",
+		"argocd/destination-server":    "https://0123ABCD.gr7.us-west-2.eks.amazonaws.com:443",
+		"argocd/destination-host":      "0123abcd.gr7.us-west-2.eks.amazonaws.com",
+		"argocd/destination-namespace": "guestbook",
+		"argocd/sync-status":           "Synced",
+		"argocd/health-status":         "Healthy",
+		"argocd/application-set":       "guestbooks",
+		"ctrlplane/links":              `{"Argo CD":"https://argocd.example.com/applications/argocd/guestbook-prod"}`,
+	}
+	for key, value := range want {
+		if app[key] != value {
+			t.Errorf("%s = %q, want %q", key, app[key], value)
+		}
+	}
+
+	multi := byIdentifier["mgmt/argocd/monitoring"].Metadata
+	if multi["argocd/source-chart"] != "kube-prometheus-stack" || multi["argocd/revision"] != "55.0.0" || multi["argocd/destination-name"] != "in-cluster" {
+		t.Errorf("unexpected multi-source metadata %v", multi)
+	}
+
+	setMetadata := byIdentifier["mgmt/argocd/guestbooks"].Metadata
+	if setMetadata["argocd/generators"] != "git,list" || setMetadata["argocd/application-count"] != "1" || setMetadata["argocd/project"] != "demo" {
+		t.Errorf("unexpected application set metadata %v", setMetadata)
+	}
+}
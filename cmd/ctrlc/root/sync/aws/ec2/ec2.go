@@ -1,7 +1,6 @@
 package ec2
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -15,10 +14,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
-	"github.com/ctrlplanedev/cli/internal/cliutil"
-	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 type ConnectionMethod struct {
@@ -47,11 +44,23 @@ func NewSyncEC2Cmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "ec2",
 		Short: "Sync AWS EC2 instances into Ctrlplane",
+		Long: heredoc.Doc(`
+			Reads the EC2 instances of a region and upserts them with their
+			network, placement and connection details as metadata.
+
+			The upsert response is written with --format or --template. Nothing
+			is written under --interval, or when the instances are unchanged
+			since the last sync and nothing was sent; --force sends them, and
+			writes the response, regardless.
+		`),
 		Example: heredoc.Doc(`
 			# Make sure AWS credentials are configured via environment variables or ~/.aws/credentials
 			
 			# Sync all EC2 instances from a region
 			$ ctrlc sync aws ec2 --region us-west-2
+
+			# Always write the upsert response, even when nothing changed
+			$ ctrlc sync aws ec2 --region us-west-2 --force --format json
 		`),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if region == "" {
@@ -62,7 +71,7 @@ func NewSyncEC2Cmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Info("Syncing EC2 instances into Ctrlplane", "config-region", region)
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 			cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
 			if err != nil {
 				return fmt.Errorf("failed to load AWS config: %w", err)
@@ -88,10 +97,6 @@ func NewSyncEC2Cmd() *cobra.Command {
 				o.RetryMode = aws.RetryModeStandard
 			})
 
-			apiURL := viper.GetString("url")
-			apiKey := viper.GetString("api-key")
-			workspaceId := viper.GetString("workspace")
-
 			// Get EC2 instances
 			result, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
 			if err != nil {
@@ -248,23 +253,7 @@ func NewSyncEC2Cmd() *cobra.Command {
 				name = fmt.Sprintf("aws-ec2-region-%s", region)
 			}
 
-			ctrlplaneClient, err := api.NewAPIKeyClientWithResponses(apiURL, apiKey)
-			if err != nil {
-				return fmt.Errorf("failed to create API client: %w", err)
-			}
-
-			rp, err := resourceprovider.New(ctrlplaneClient, workspaceId, name)
-			if err != nil {
-				return fmt.Errorf("failed to create resource provider: %w", err)
-			}
-
-			upsertResp, err := rp.UpsertResource(ctx, resources)
-			log.Info("Response from upserting resources", "status", upsertResp.Status)
-			if err != nil {
-				return fmt.Errorf("failed to upsert resources: %w", err)
-			}
-
-			return cliutil.HandleResponseOutput(cmd, upsertResp)
+			return ctrlp.UpsertResources(ctx, resources, &name, ctrlp.WithOutput(cmd))
 		},
 	}
 
@@ -15,9 +15,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/aws/common"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/kubernetes"
 	"github.com/ctrlplanedev/cli/internal/api"
 	ctrlp "github.com/ctrlplanedev/cli/internal/common"
 	"github.com/ctrlplanedev/cli/internal/kinds"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +27,7 @@ import (
 func NewSyncEKSCmd() *cobra.Command {
 	var regions []string
 	var name string
+	var workloads kubernetes.WorkloadOptions
 
 	cmd := &cobra.Command{
 		Use:   "eks",
@@ -40,19 +43,23 @@ func NewSyncEKSCmd() *cobra.Command {
 
 			# Sync all EKS clusters from all regions
 			$ ctrlc sync aws eks
+
+			# Also sync the namespaces, deployments, nodes and Helm releases in every cluster
+			$ ctrlc sync aws eks --region us-west-2 --with-workloads --with-helm
 		`),
-		RunE: runSync(&regions, &name),
+		RunE: runSync(&regions, &name, &workloads),
 	}
 
 	cmd.Flags().StringVarP(&name, "provider", "p", "", "Name of the resource provider")
 	cmd.Flags().StringSliceVarP(&regions, "region", "r", []string{}, "AWS Region(s)")
+	workloads.AddFlags(cmd.Flags())
 
 	return cmd
 }
 
-func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []string) error {
+func runSync(regions *[]string, name *string, workloads *kubernetes.WorkloadOptions) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Get the regions to sync from using common package
 		regionsToSync, err := common.GetRegions(ctx, *regions)
@@ -64,6 +71,7 @@ func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []st
 
 		// Process each region
 		var allResources []api.ResourceProviderResource
+		var allClusters []kubernetes.Cluster
 		var mu sync.Mutex
 		var wg sync.WaitGroup
 		var syncErrors []error
@@ -84,7 +92,7 @@ func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []st
 				}
 
 				// List and process clusters for this region
-				resources, err := processClusters(ctx, eksClient, regionName, cfg)
+				resources, clusters, err := processClusters(ctx, eksClient, regionName, cfg, workloads.Enabled)
 				if err != nil {
 					log.Error("Failed to process clusters", "region", regionName, "error", err)
 					mu.Lock()
@@ -96,6 +104,7 @@ func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []st
 				if len(resources) > 0 {
 					mu.Lock()
 					allResources = append(allResources, resources...)
+					allClusters = append(allClusters, clusters...)
 					mu.Unlock()
 				}
 			}(r)
@@ -116,7 +125,11 @@ func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []st
 		common.EnsureProviderDetails(ctx, "aws-eks", regionsToSync, name)
 
 		// Upsert resources to Ctrlplane
-		return ctrlp.UpsertResources(ctx, allResources, name)
+		if err := ctrlp.UpsertResources(ctx, allResources, name, ctrlp.WithVariables(ctrlp.ClusterVariables(allResources))); err != nil {
+			return err
+		}
+
+		return kubernetes.SyncWorkloads(ctx, *workloads, allClusters)
 	}
 }
 
@@ -130,13 +143,16 @@ func initEKSClient(ctx context.Context, region string) (*eks.Client, aws.Config,
 	return eks.NewFromConfig(cfg), cfg, nil
 }
 
-func processClusters(ctx context.Context, eksClient *eks.Client, region string, cfg aws.Config) ([]api.ResourceProviderResource, error) {
+// processClusters lists the clusters of a region. With workloads it also
+// returns the credentials to reach every active cluster.
+func processClusters(ctx context.Context, eksClient *eks.Client, region string, cfg aws.Config, workloads bool) ([]api.ResourceProviderResource, []kubernetes.Cluster, error) {
 	var resources []api.ResourceProviderResource
+	var clusters []kubernetes.Cluster
 	var nextToken *string
 
 	accountID, err := common.GetAccountID(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get AWS account ID: %w", err)
+		return nil, nil, fmt.Errorf("failed to get AWS account ID: %w", err)
 	}
 
 	for {
@@ -144,7 +160,7 @@ func processClusters(ctx context.Context, eksClient *eks.Client, region string,
 			NextToken: nextToken,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to list EKS clusters: %w", err)
+			return nil, nil, fmt.Errorf("failed to list EKS clusters: %w", err)
 		}
 
 		for _, clusterName := range resp.Clusters {
@@ -162,6 +178,15 @@ func processClusters(ctx context.Context, eksClient *eks.Client, region string,
 				continue
 			}
 			resources = append(resources, resource)
+
+			if workloads && cluster.Cluster.Status == types.ClusterStatusActive {
+				workload, err := workloadCluster(ctx, cfg, cluster.Cluster, accountID, region)
+				if err != nil {
+					log.Error("Failed to get EKS cluster credentials", "name", clusterName, "error", err)
+					continue
+				}
+				clusters = append(clusters, workload)
+			}
 		}
 
 		if resp.NextToken == nil {
@@ -171,7 +196,7 @@ func processClusters(ctx context.Context, eksClient *eks.Client, region string,
 	}
 
 	log.Info("Found EKS clusters", "region", region, "count", len(resources))
-	return resources, nil
+	return resources, clusters, nil
 }
 
 func processCluster(_ context.Context, cluster *types.Cluster, region string, accountID string) (api.ResourceProviderResource, error) {
@@ -242,6 +267,7 @@ func initClusterMetadata(cluster *types.Cluster, region string) map[string]strin
 		kinds.K8SMetadataVersionPatch:      strconv.FormatUint(uint64(version.Patch()), 10),
 		kinds.K8SMetadataVersionPrerelease: version.Prerelease(),
 		kinds.K8SMetadataStatus:            normalizedStatus,
+		kinds.K8SMetadataServerHost:        kubeconfig.ServerHost(aws.ToString(cluster.Endpoint)),
 
 		"aws/region":           region,
 		"aws/resource-type":    "eks:cluster",
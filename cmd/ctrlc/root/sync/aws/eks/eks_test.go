@@ -0,0 +1,32 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/ctrlplanedev/cli/internal/kinds"
+)
+
+func TestProcessCluster_ServerHost(t *testing.T) {
+	cluster := &types.Cluster{
+		Name:                 aws.String("prod"),
+		Arn:                  aws.String("arn:aws:eks:us-west-2:123456789012:cluster/prod"),
+		Version:              aws.String("1.30"),
+		PlatformVersion:      aws.String("eks.8"),
+		Status:               types.ClusterStatusActive,
+		Endpoint:             aws.String("https://0123ABCD.gr7.us-west-2.eks.amazonaws.com"),
+		CertificateAuthority: &types.Certificate{Data: aws.String("Y2E=")},
+		ResourcesVpcConfig:   &types.VpcConfigResponse{VpcId: aws.String("vpc-1")},
+	}
+	resource, err := processCluster(context.Background(), cluster, "us-west-2", "123456789012")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Argo CD registers EKS clusters by their endpoint, which the argocd
+	// sync normalizes the same way for its destination rule.
+	if got := resource.Metadata[kinds.K8SMetadataServerHost]; got != "0123abcd.gr7.us-west-2.eks.amazonaws.com" {
+		t.Errorf("server host = %q", got)
+	}
+}
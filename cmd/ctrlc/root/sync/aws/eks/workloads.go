@@ -0,0 +1,113 @@
+package eks
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// workloadCluster builds the client configuration for a cluster from the AWS
+// credentials the clusters were listed with.
+func workloadCluster(ctx context.Context, cfg aws.Config, cluster *types.Cluster, accountID, region string) (kubernetes.Cluster, error) {
+	ca, err := base64.StdEncoding.DecodeString(*cluster.CertificateAuthority.Data)
+	if err != nil {
+		return kubernetes.Cluster{}, fmt.Errorf("failed to decode certificate authority: %w", err)
+	}
+	// Fail discovery early on credentials that cannot presign at all.
+	if _, err := clusterToken(ctx, cfg, *cluster.Name); err != nil {
+		return kubernetes.Cluster{}, err
+	}
+	return kubernetes.Cluster{
+		Identifier: *cluster.Arn,
+		Name:       *cluster.Name,
+		Provider:   fmt.Sprintf("aws-eks-%s-%s-%s", accountID, region, *cluster.Name),
+		Config: &rest.Config{
+			Host:            *cluster.Endpoint,
+			TLSClientConfig: rest.TLSClientConfig{CAData: ca},
+			WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+				return &tokenTransport{base: rt, cfg: cfg, clusterName: *cluster.Name}
+			},
+		},
+	}, nil
+}
+
+// tokenRefresh is how long a token is reused. EKS accepts a token for 15
+// minutes, and workloads are synced long after discovery.
+const tokenRefresh = 10 * time.Minute
+
+// tokenTransport authenticates every request with an EKS token, presigning a
+// new one when the current token is about to expire.
+type tokenTransport struct {
+	base        http.RoundTripper
+	cfg         aws.Config
+	clusterName string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.currentToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+func (t *tokenTransport) currentToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+	token, err := clusterToken(ctx, t.cfg, t.clusterName)
+	if err != nil {
+		return "", err
+	}
+	t.token, t.expires = token, time.Now().Add(tokenRefresh)
+	return token, nil
+}
+
+// clusterToken returns an EKS bearer token, which is a presigned STS
+// GetCallerIdentity URL bound to the cluster name, as produced by
+// `aws eks get-token`. It is valid for 15 minutes.
+func clusterToken(ctx context.Context, cfg aws.Config, clusterName string) (string, error) {
+	presigner := sts.NewPresignClient(sts.NewFromConfig(cfg))
+	request, err := presigner.PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(o *sts.PresignOptions) {
+		o.ClientOptions = append(o.ClientOptions, func(o *sts.Options) {
+			o.APIOptions = append(o.APIOptions, smithyhttp.AddHeaderValue("x-k8s-aws-id", clusterName), addExpires)
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to presign EKS token: %w", err)
+	}
+	return "k8s-aws-v1." + base64.RawURLEncoding.EncodeToString([]byte(request.URL)), nil
+}
+
+// addExpires sets X-Amz-Expires before signing, as the EKS authenticator
+// expects of a token.
+func addExpires(stack *middleware.Stack) error {
+	return stack.Build.Add(middleware.BuildMiddlewareFunc("EKSTokenExpires",
+		func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+			if request, ok := in.Request.(*smithyhttp.Request); ok {
+				query := request.URL.Query()
+				query.Set("X-Amz-Expires", "60")
+				request.URL.RawQuery = query.Encode()
+			}
+			return next.HandleBuild(ctx, in)
+		}), middleware.After)
+}
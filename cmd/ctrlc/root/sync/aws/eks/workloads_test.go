@@ -0,0 +1,88 @@
+package eks
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestClusterToken(t *testing.T) {
+	cfg := aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+	}
+	token, err := clusterToken(context.Background(), cfg, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, ok := strings.CutPrefix(token, "k8s-aws-v1.")
+	if !ok {
+		t.Fatalf("expected a k8s-aws-v1 token, got %q", token)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	presigned, err := url.Parse(string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := presigned.Query()
+	if query.Get("Action") != "GetCallerIdentity" || query.Get("X-Amz-Expires") != "60" {
+		t.Errorf("unexpected presigned request %s", presigned)
+	}
+	if !strings.Contains(query.Get("X-Amz-SignedHeaders"), "x-k8s-aws-id") {
+		t.Errorf("expected the cluster header to be signed, got %q", query.Get("X-Amz-SignedHeaders"))
+	}
+}
+
+func TestTokenTransport_Refreshes(t *testing.T) {
+	cfg := aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+	}
+	var seen []string
+	transport := &tokenTransport{
+		base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			seen = append(seen, req.Header.Get("Authorization"))
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+		cfg:         cfg,
+		clusterName: "prod",
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "https://prod.eks.amazonaws.com/api", nil)
+	for range 2 {
+		if _, err := transport.RoundTrip(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if seen[0] == "" || seen[0] != seen[1] {
+		t.Errorf("expected the token to be reused, got %q", seen)
+	}
+	if request.Header.Get("Authorization") != "" {
+		t.Error("expected the original request to be left unmodified")
+	}
+
+	transport.expires = time.Now().Add(-time.Second)
+	first := transport.token
+	if _, err := transport.RoundTrip(request); err != nil {
+		t.Fatal(err)
+	}
+	if transport.token == "" || !transport.expires.After(time.Now()) || seen[2] != "Bearer "+transport.token {
+		t.Errorf("expected an expired token to be replaced, had %q", first)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -45,7 +45,7 @@ func NewSyncNetworksCmd() *cobra.Command {
 // runSync contains the main sync logic
 func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Get the regions to sync from using common package
 		regionsToSync, err := common.GetRegions(ctx, *regions)
@@ -65,7 +65,7 @@ func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []st
 				defer wg.Done()
 				log.Info("Syncing AWS Network resources into Ctrlplane", "region", regionName)
 
-				ctx := context.Background()
+				ctx := cmd.Context()
 
 				//apiURL := viper.GetString("url")
 				//apiKey := viper.GetString("api-key")
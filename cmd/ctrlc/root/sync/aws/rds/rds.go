@@ -50,7 +50,7 @@ func NewSyncRDSCmd() *cobra.Command {
 
 func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Get the regions to sync from using common package
 		regionsToSync, err := common.GetRegions(ctx, *regions)
@@ -145,7 +145,10 @@ func runSync(regions *[]string, name *string) func(cmd *cobra.Command, args []st
 		}
 
 		// Upsert resources to Ctrlplane
-		return ctrlp.UpsertResources(ctx, allResources, name)
+		return ctrlp.UpsertResources(ctx, allResources, name,
+			ctrlp.WithRelationshipRules(relationshipRules...),
+			ctrlp.WithVariables(ctrlp.DatabaseVariables(allResources)),
+		)
 	}
 }
 
@@ -650,24 +653,20 @@ func buildInstanceMetadata(instance *types.DBInstance, region, host string, port
 	return metadata
 }
 
-// var relationshipRules = []api.ResourceProviderResourceRelationshipRule{
-// 	{
-// 		Reference:      "network",
-// 		Name:           "AWS RDS Network",
-// 		DependencyType: api.ProvisionedIn,
-//
-// 		SourceVersion: "ctrlplane.dev/database/v1",
-// 		SourceKind:    "AmazonRelationalDatabaseService",
-//
-// 		TargetVersion: "ctrlplane.dev/network/v1",
-// 		TargetKind:    "AmazonNetwork",
-//
-// 		MetadataKeysMatches: &[]api.MetadataKeyMatchConstraint{
-// 			{SourceKey: "aws/region", TargetKey: "aws/region"},
-// 			{SourceKey: "network/name", TargetKey: "network/name"},
-// 		},
-// 	},
-// }
+// relationshipRules links RDS instances and clusters to the VPC they run in,
+// as synced by `ctrlc sync aws networks`.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "network",
+		Name:      "AWS RDS Network",
+		Cel: `from.version == "ctrlplane.dev/database/v1" &&
+			from.kind.startsWith("AmazonRelationalDatabase") &&
+			to.version == "ctrlplane.dev/network/v1" &&
+			to.kind == "AmazonNetwork" &&
+			from.metadata["aws/region"] == to.metadata["aws/region"] &&
+			from.metadata["network/vpc"] == to.metadata["network/id"]`,
+	},
+}
 
 // fetchParameterGroupDetails retrieves parameters from a parameter group and adds them to metadata
 func fetchParameterGroupDetails(ctx context.Context, rdsClient *rds.Client, parameterGroupName string, metadata map[string]string) {
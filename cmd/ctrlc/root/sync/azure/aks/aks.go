@@ -17,9 +17,11 @@ import (
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/Masterminds/semver"
 	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/kubernetes"
 	"github.com/ctrlplanedev/cli/internal/api"
 	ctrlp "github.com/ctrlplanedev/cli/internal/common"
 	"github.com/ctrlplanedev/cli/internal/kinds"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -28,6 +30,7 @@ import (
 func NewSyncAKSCmd() *cobra.Command {
 	var subscriptionID string
 	var name string
+	var workloads kubernetes.WorkloadOptions
 
 	cmd := &cobra.Command{
 		Use:   "aks",
@@ -43,19 +46,23 @@ func NewSyncAKSCmd() *cobra.Command {
 			
 			# Sync all AKS clusters every 5 minutes
 			$ ctrlc sync azure aks --interval 5m
+
+			# Also sync the namespaces, deployments, nodes and Helm releases in every cluster
+			$ ctrlc sync azure aks --with-workloads --with-helm
 		`),
-		RunE: runSync(&subscriptionID, &name),
+		RunE: runSync(&subscriptionID, &name, &workloads),
 	}
 
 	cmd.Flags().StringVarP(&name, "provider", "p", "", "Name of the resource provider")
 	cmd.Flags().StringVarP(&subscriptionID, "subscription-id", "s", "", "Azure Subscription ID")
+	workloads.AddFlags(cmd.Flags())
 
 	return cmd
 }
 
-func runSync(subscriptionID, name *string) func(cmd *cobra.Command, args []string) error {
+func runSync(subscriptionID, name *string, workloads *kubernetes.WorkloadOptions) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize Azure credential from environment or CLI
 		cred, err := azidentity.NewDefaultAzureCredential(nil)
@@ -83,7 +90,7 @@ func runSync(subscriptionID, name *string) func(cmd *cobra.Command, args []strin
 		log.Info("Syncing all AKS clusters", "subscriptionID", *subscriptionID, "tenantID", tenantID)
 
 		// Process AKS clusters
-		resources, err := processClusters(ctx, cred, *subscriptionID, tenantID)
+		resources, clusters, err := processClusters(ctx, cred, *subscriptionID, tenantID, workloads.Enabled)
 		if err != nil {
 			return err
 		}
@@ -99,7 +106,14 @@ func runSync(subscriptionID, name *string) func(cmd *cobra.Command, args []strin
 		}
 
 		// Upsert resources to Ctrlplane
-		return ctrlp.UpsertResources(ctx, resources, name)
+		if err := ctrlp.UpsertResources(ctx, resources, name,
+			ctrlp.WithRelationshipRules(relationshipRules...),
+			ctrlp.WithVariables(ctrlp.ClusterVariables(resources)),
+		); err != nil {
+			return err
+		}
+
+		return kubernetes.SyncWorkloads(ctx, *workloads, clusters)
 	}
 }
 
@@ -160,8 +174,11 @@ func getDefaultSubscriptionID(ctx context.Context, cred azcore.TokenCredential)
 	return "", fmt.Errorf("no subscriptions found")
 }
 
-func processClusters(ctx context.Context, cred azcore.TokenCredential, subscriptionID string, tenantID string) ([]api.ResourceProviderResource, error) {
+// processClusters lists the clusters of the subscription. With workloads it
+// also returns the credentials to reach every running cluster.
+func processClusters(ctx context.Context, cred azcore.TokenCredential, subscriptionID string, tenantID string, workloads bool) ([]api.ResourceProviderResource, []kubernetes.Cluster, error) {
 	var resources []api.ResourceProviderResource
+	var clusters []kubernetes.Cluster
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	var syncErrors []error
@@ -169,7 +186,7 @@ func processClusters(ctx context.Context, cred azcore.TokenCredential, subscript
 	// Create AKS client
 	aksClient, err := armcontainerservice.NewManagedClustersClient(subscriptionID, cred, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AKS client: %w", err)
+		return nil, nil, fmt.Errorf("failed to create AKS client: %w", err)
 	}
 
 	// List all clusters in the subscription
@@ -177,7 +194,7 @@ func processClusters(ctx context.Context, cred azcore.TokenCredential, subscript
 	for pager.More() {
 		page, err := pager.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list AKS clusters: %w", err)
+			return nil, nil, fmt.Errorf("failed to list AKS clusters: %w", err)
 		}
 
 		for _, cluster := range page.Value {
@@ -197,6 +214,18 @@ func processClusters(ctx context.Context, cred azcore.TokenCredential, subscript
 				mu.Lock()
 				resources = append(resources, resource)
 				mu.Unlock()
+
+				if !workloads || !isRunning(mc) {
+					return
+				}
+				workload, err := workloadCluster(ctx, aksClient, cred, mc, subscriptionID)
+				if err != nil {
+					log.Error("Failed to get AKS cluster credentials", "name", *mc.Name, "error", err)
+					return
+				}
+				mu.Lock()
+				clusters = append(clusters, workload)
+				mu.Unlock()
 			}(cluster)
 		}
 	}
@@ -209,7 +238,7 @@ func processClusters(ctx context.Context, cred azcore.TokenCredential, subscript
 	}
 
 	log.Info("Found AKS clusters", "count", len(resources))
-	return resources, nil
+	return resources, clusters, nil
 }
 
 func processCluster(_ context.Context, cluster *armcontainerservice.ManagedCluster, subscriptionID string, tenantID string) (api.ResourceProviderResource, error) {
@@ -283,8 +312,9 @@ func initClusterMetadata(cluster *armcontainerservice.ManagedCluster, subscripti
 		kinds.K8SMetadataVersionPatch:      strconv.FormatUint(uint64(version.Patch()), 10),
 		kinds.K8SMetadataVersionPrerelease: version.Prerelease(),
 
-		"kubernetes/location": *cluster.Location,
-		"kubernetes/endpoint": getEndpoint(cluster),
+		"kubernetes/location":       *cluster.Location,
+		"kubernetes/endpoint":       getEndpoint(cluster),
+		kinds.K8SMetadataServerHost: kubeconfig.ServerHost(getEndpoint(cluster)),
 
 		"azure/subscription":   subscriptionID,
 		"azure/tenant":         tenantID,
@@ -417,21 +447,17 @@ func extractResourceGroupFromID(id string) string {
 	return ""
 }
 
-// var relationshipRules = []api.ResourceProviderResourceRelationshipRule{
-// 	{
-// 		Reference:      "network",
-// 		Name:           "Azure Cluster Network",
-// 		DependencyType: api.ProvisionedIn,
-//
-// 		SourceVersion: "ctrlplane.dev/kubernetes/cluster/v1",
-// 		SourceKind:    "AzureKubernetesService",
-//
-// 		TargetVersion: "ctrlplane.dev/network/v1",
-// 		TargetKind:    "AzureNetwork",
-//
-// 		MetadataKeysMatches: &[]api.MetadataKeyMatchConstraint{
-// 			{SourceKey: "azure/subscription", TargetKey: "azure/subscription"},
-// 			{SourceKey: "azure/resource-group", TargetKey: "azure/resource-group"},
-// 		},
-// 	},
-// }
+// relationshipRules links AKS clusters to the virtual networks in their
+// resource group, as synced by `ctrlc sync azure networks`.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "network",
+		Name:      "Azure Cluster Network",
+		Cel: `from.version == "ctrlplane.dev/kubernetes/cluster/v1" &&
+			from.kind == "AzureKubernetesService" &&
+			to.version == "ctrlplane.dev/network/v1" &&
+			to.kind == "AzureNetwork" &&
+			from.metadata["azure/subscription"] == to.metadata["azure/subscription"] &&
+			from.metadata["azure/resource-group"] == to.metadata["azure/resource-group"]`,
+	},
+}
@@ -0,0 +1,125 @@
+package aks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// aadServerScope is the scope of the AKS AAD server application, whose
+// tokens Entra ID enabled clusters accept.
+const aadServerScope = "6dae42f8-4368-4678-94ff-3960e28e3630/.default"
+
+// isRunning reports whether the cluster is provisioned and not stopped.
+func isRunning(cluster *armcontainerservice.ManagedCluster) bool {
+	properties := cluster.Properties
+	if properties == nil || properties.ProvisioningState == nil || *properties.ProvisioningState != "Succeeded" {
+		return false
+	}
+	return properties.PowerState == nil || properties.PowerState.Code == nil || *properties.PowerState.Code == armcontainerservice.CodeRunning
+}
+
+// workloadCluster builds the client configuration for a cluster from its
+// user credentials.
+func workloadCluster(ctx context.Context, client *armcontainerservice.ManagedClustersClient, cred azcore.TokenCredential, cluster *armcontainerservice.ManagedCluster, subscriptionID string) (kubernetes.Cluster, error) {
+	resourceGroup := extractResourceGroupFromID(*cluster.ID)
+	resp, err := client.ListClusterUserCredentials(ctx, resourceGroup, *cluster.Name, nil)
+	if err != nil {
+		return kubernetes.Cluster{}, fmt.Errorf("failed to get cluster credentials: %w", err)
+	}
+	if len(resp.Kubeconfigs) == 0 {
+		return kubernetes.Cluster{}, fmt.Errorf("cluster returned no kubeconfig")
+	}
+	config, err := clusterConfig(ctx, resp.Kubeconfigs[0].Value, cred)
+	if err != nil {
+		return kubernetes.Cluster{}, err
+	}
+
+	return kubernetes.Cluster{
+		Identifier: *cluster.ID,
+		Name:       *cluster.Name,
+		Provider:   fmt.Sprintf("azure-aks-%s-%s-%s", subscriptionID, resourceGroup, *cluster.Name),
+		Config:     config,
+	}, nil
+}
+
+// clusterConfig parses a cluster's user kubeconfig. Entra ID enabled
+// clusters return a kubeconfig that runs kubelogin; those authenticate with
+// the credential the clusters were listed with instead.
+func clusterConfig(ctx context.Context, kubeconfig []byte, cred azcore.TokenCredential) (*rest.Config, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cluster kubeconfig: %w", err)
+	}
+	if config.ExecProvider == nil && config.AuthProvider == nil {
+		return config, nil
+	}
+
+	// Fail discovery early on credentials that cannot get a token at all.
+	if _, err := clusterToken(ctx, cred); err != nil {
+		return nil, err
+	}
+	config.ExecProvider = nil
+	config.AuthProvider = nil
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return &tokenTransport{base: rt, cred: cred}
+	}
+	return config, nil
+}
+
+// tokenExpiryMargin is how long before its expiry a token is replaced, so
+// that it does not expire in flight.
+const tokenExpiryMargin = 5 * time.Minute
+
+// tokenTransport authenticates every request with an Entra ID token, getting
+// a new one when the current token is about to expire. Workloads are synced
+// long after discovery, often past the lifetime of the first token.
+type tokenTransport struct {
+	base http.RoundTripper
+	cred azcore.TokenCredential
+
+	mu    sync.Mutex
+	token azcore.AccessToken
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.currentToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+func (t *tokenTransport) currentToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token.Token != "" && time.Now().Add(tokenExpiryMargin).Before(t.token.ExpiresOn) {
+		return t.token.Token, nil
+	}
+	token, err := clusterToken(ctx, t.cred)
+	if err != nil {
+		return "", err
+	}
+	t.token = token
+	return token.Token, nil
+}
+
+// clusterToken gets a token for the AKS AAD server application.
+func clusterToken(ctx context.Context, cred azcore.TokenCredential) (azcore.AccessToken, error) {
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{aadServerScope}})
+	if err != nil {
+		return azcore.AccessToken{}, fmt.Errorf("failed to get cluster token: %w", err)
+	}
+	return token, nil
+}
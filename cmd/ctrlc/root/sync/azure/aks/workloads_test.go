@@ -0,0 +1,115 @@
+package aks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const kubeloginConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.hcp.eastus.azmk8s.io:443
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: clusterUser
+current-context: prod
+users:
+- name: clusterUser
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: kubelogin
+      args: [get-token, --server-id, 6dae42f8-4368-4678-94ff-3960e28e3630]
+`
+
+// fakeCredential hands out numbered tokens that expire after ttl.
+type fakeCredential struct {
+	ttl    time.Duration
+	err    error
+	calls  int
+	scopes []string
+}
+
+func (c *fakeCredential) GetToken(_ context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	c.calls++
+	c.scopes = options.Scopes
+	return azcore.AccessToken{Token: fmt.Sprintf("token-%d", c.calls), ExpiresOn: time.Now().Add(c.ttl)}, nil
+}
+
+func TestClusterConfig_Kubelogin(t *testing.T) {
+	cred := &fakeCredential{ttl: time.Hour}
+	config, err := clusterConfig(context.Background(), []byte(kubeloginConfig), cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ExecProvider != nil || config.AuthProvider != nil || config.BearerToken != "" {
+		t.Errorf("expected kubelogin to be replaced by a per-request token, got %+v", config)
+	}
+	if config.WrapTransport == nil {
+		t.Fatal("expected a token transport")
+	}
+	if cred.calls != 1 || !slices.Equal(cred.scopes, []string{aadServerScope}) {
+		t.Errorf("expected the credential to be checked once for %s, got %d calls for %v", aadServerScope, cred.calls, cred.scopes)
+	}
+}
+
+func TestClusterConfig_CredentialFails(t *testing.T) {
+	cred := &fakeCredential{err: errors.New("no identity")}
+	if _, err := clusterConfig(context.Background(), []byte(kubeloginConfig), cred); err == nil {
+		t.Error("expected a credential that cannot get a token to fail discovery")
+	}
+}
+
+func TestTokenTransport_Refreshes(t *testing.T) {
+	cred := &fakeCredential{ttl: time.Hour}
+	var seen []string
+	transport := &tokenTransport{
+		base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			seen = append(seen, req.Header.Get("Authorization"))
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+		cred: cred,
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "https://prod.hcp.eastus.azmk8s.io/api", nil)
+	for range 2 {
+		if _, err := transport.RoundTrip(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(seen, []string{"Bearer token-1", "Bearer token-1"}) {
+		t.Errorf("expected the token to be reused, got %q", seen)
+	}
+	if request.Header.Get("Authorization") != "" {
+		t.Error("expected the original request to be left unmodified")
+	}
+
+	// A token within the expiry margin is replaced before it is sent.
+	transport.token.ExpiresOn = time.Now().Add(tokenExpiryMargin / 2)
+	if _, err := transport.RoundTrip(request); err != nil {
+		t.Fatal(err)
+	}
+	if seen[2] != "Bearer token-2" {
+		t.Errorf("expected an expiring token to be replaced, got %q", seen[2])
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -52,7 +52,7 @@ func NewSyncNetworksCmd() *cobra.Command {
 
 func runSync(subscriptionID, name *string) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize Azure credential from environment or CLI
 		cred, err := azidentity.NewDefaultAzureCredential(nil)
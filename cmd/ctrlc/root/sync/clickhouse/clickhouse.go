@@ -219,7 +219,7 @@ func NewSyncClickhouseCmd() *cobra.Command {
 			}
 
 			chClient := NewClickHouseClient(clickhouseApiUrl, clickhouseApiId, clickhouseApiSecret, organizationID)
-			ctx := context.Background()
+			ctx := cmd.Context()
 			services, err := chClient.GetServices(ctx)
 			if err != nil {
 				return fmt.Errorf("failed to list ClickHouse services: %w", err)
@@ -0,0 +1,189 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultConcurrency    = 4
+	defaultMetricsAddress = ":9090"
+	defaultRetryDelay     = 30 * time.Second
+	defaultMaxBackoff     = 30 * time.Minute
+)
+
+// Config is the top-level document accepted by `ctrlc sync daemon --config`.
+type Config struct {
+	// MetricsAddress is the listen address for /healthz and /metrics. Set to
+	// "-" to disable the HTTP server.
+	MetricsAddress string `yaml:"metricsAddress"`
+	// Concurrency limits how many jobs may run at the same time.
+	Concurrency int `yaml:"concurrency"`
+	// RetryDelay is the first backoff delay after a failed run.
+	RetryDelay Duration `yaml:"retryDelay"`
+	// MaxBackoff caps the exponential backoff between failed runs.
+	MaxBackoff Duration `yaml:"maxBackoff"`
+	Jobs       []Job    `yaml:"jobs"`
+}
+
+// Job describes a single integration run by the daemon, e.g. `aws rds` with
+// its flags and schedule.
+type Job struct {
+	Name string `yaml:"name"`
+	// Command is the sync subcommand path, e.g. "aws rds" or "kubernetes".
+	Command string `yaml:"command"`
+	// Flags are passed to the subcommand as --key=value. List values are
+	// repeated, boolean values are passed as --key=true/false.
+	Flags map[string]any `yaml:"flags"`
+	// Args are appended after the flags.
+	Args []string `yaml:"args"`
+	// Env adds environment variables to the job process.
+	Env map[string]string `yaml:"env"`
+
+	Interval Duration `yaml:"interval"`
+	Cron     string   `yaml:"cron"`
+	Jitter   Duration `yaml:"jitter"`
+	Timeout  Duration `yaml:"timeout"`
+
+	schedule cron.Schedule
+}
+
+// Duration wraps time.Duration so it can be written as "5m" in YAML.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var raw string
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	if raw == "" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", raw, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// LoadConfig reads and validates a daemon configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses a daemon configuration document, applies defaults and
+// validates every job.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon config: %w", err)
+	}
+
+	if cfg.MetricsAddress == "" {
+		cfg.MetricsAddress = defaultMetricsAddress
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = Duration(defaultRetryDelay)
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = Duration(defaultMaxBackoff)
+	}
+
+	if len(cfg.Jobs) == 0 {
+		return nil, fmt.Errorf("daemon config must define at least one job")
+	}
+
+	seen := make(map[string]bool, len(cfg.Jobs))
+	for i := range cfg.Jobs {
+		job := &cfg.Jobs[i]
+		if err := job.validate(); err != nil {
+			return nil, fmt.Errorf("jobs[%d]: %w", i, err)
+		}
+		if seen[job.Name] {
+			return nil, fmt.Errorf("jobs[%d]: duplicate job name %q", i, job.Name)
+		}
+		seen[job.Name] = true
+	}
+
+	return &cfg, nil
+}
+
+func (j *Job) validate() error {
+	if strings.TrimSpace(j.Command) == "" {
+		return fmt.Errorf("missing required field 'command'")
+	}
+	if j.Name == "" {
+		j.Name = strings.Join(strings.Fields(j.Command), "-")
+	}
+	if j.Interval > 0 && j.Cron != "" {
+		return fmt.Errorf("job %q: set either 'interval' or 'cron', not both", j.Name)
+	}
+	if j.Interval <= 0 && j.Cron == "" {
+		return fmt.Errorf("job %q: one of 'interval' or 'cron' is required", j.Name)
+	}
+	if j.Cron != "" {
+		schedule, err := cron.ParseStandard(j.Cron)
+		if err != nil {
+			return fmt.Errorf("job %q: invalid cron expression: %w", j.Name, err)
+		}
+		j.schedule = schedule
+	}
+	if j.Jitter < 0 || j.Timeout < 0 {
+		return fmt.Errorf("job %q: jitter and timeout must not be negative", j.Name)
+	}
+	return nil
+}
+
+// Next returns the next scheduled run after the given time, without jitter.
+func (j *Job) Next(after time.Time) time.Time {
+	if j.schedule != nil {
+		return j.schedule.Next(after)
+	}
+	return after.Add(j.Interval.Std())
+}
+
+// CommandArgs builds the argument list for `ctrlc sync ...` from the job's
+// command, flags and args. Flags are sorted so runs are reproducible.
+func (j *Job) CommandArgs() []string {
+	args := append([]string{"sync"}, strings.Fields(j.Command)...)
+
+	keys := make([]string, 0, len(j.Flags))
+	for key := range j.Flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch value := j.Flags[key].(type) {
+		case []any:
+			for _, item := range value {
+				args = append(args, fmt.Sprintf("--%s=%v", key, item))
+			}
+		case nil:
+			args = append(args, "--"+key)
+		default:
+			args = append(args, fmt.Sprintf("--%s=%v", key, value))
+		}
+	}
+
+	return append(args, j.Args...)
+}
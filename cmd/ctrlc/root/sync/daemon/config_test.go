@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseConfig_DefaultsAndArgs(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+jobs:
+  - command: aws rds
+    interval: 5m
+    flags:
+      region: [us-east-1, us-west-2]
+      provider: aws-rds
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	if cfg.Concurrency != defaultConcurrency {
+		t.Fatalf("expected default concurrency %d, got %d", defaultConcurrency, cfg.Concurrency)
+	}
+
+	job := cfg.Jobs[0]
+	if job.Name != "aws-rds" {
+		t.Fatalf("expected job name to default to aws-rds, got %q", job.Name)
+	}
+
+	expected := []string{"sync", "aws", "rds", "--provider=aws-rds", "--region=us-east-1", "--region=us-west-2"}
+	if got := job.CommandArgs(); !slices.Equal(got, expected) {
+		t.Fatalf("expected args %v, got %v", expected, got)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if next := job.Next(start); !next.Equal(start.Add(5 * time.Minute)) {
+		t.Fatalf("expected next run 5m after start, got %s", next)
+	}
+}
+
+func TestParseConfig_Cron(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+jobs:
+  - name: hourly
+    command: tailscale
+    cron: "0 * * * *"
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)
+	expected := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	if next := cfg.Jobs[0].Next(start); !next.Equal(expected) {
+		t.Fatalf("expected next run at %s, got %s", expected, next)
+	}
+}
+
+func TestParseConfig_InvalidSchedule(t *testing.T) {
+	cases := map[string]string{
+		"missing schedule": "jobs:\n  - command: tailscale\n",
+		"both schedules":   "jobs:\n  - command: tailscale\n    interval: 5m\n    cron: \"0 * * * *\"\n",
+		"bad cron":         "jobs:\n  - command: tailscale\n    cron: \"not a cron\"\n",
+		"no jobs":          "concurrency: 2\n",
+	}
+	for name, input := range cases {
+		if _, err := ParseConfig([]byte(input)); err == nil {
+			t.Fatalf("%s: expected ParseConfig to fail", name)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/cliutil"
+	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// shutdownGracePeriod is how long a running job gets to exit after SIGTERM
+// before it is killed.
+const shutdownGracePeriod = 30 * time.Second
+
+func NewSyncDaemonCmd() *cobra.Command {
+	var configPath string
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run many sync integrations on their own schedules from one config file",
+		Long: heredoc.Doc(`
+			Runs every job listed in the config file on its own interval or cron
+			schedule. Each run executes "ctrlc sync <command>" as a child process,
+			so a crashing integration never takes down the daemon. Failed runs are
+			retried with exponential backoff, and /healthz and /metrics are served
+			for liveness probes and Prometheus.
+		`),
+		Example: heredoc.Doc(`
+			$ ctrlc sync daemon --config sync.yaml
+
+			# sync.yaml
+			metricsAddress: ":9090"
+			concurrency: 2
+			jobs:
+			  - name: rds
+			    command: aws rds
+			    interval: 5m
+			    jitter: 30s
+			    timeout: 10m
+			    flags:
+			      region: [us-east-1, us-west-2]
+			  - name: tailscale
+			    command: tailscale
+			    cron: "0 * * * *"
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if err := validateCommands(cmd.Parent(), cfg.Jobs); err != nil {
+				return err
+			}
+
+			executable, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to resolve ctrlc executable: %w", err)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			d := &daemon{
+				cfg:        cfg,
+				executable: executable,
+				slots:      make(chan struct{}, cfg.Concurrency),
+				metrics:    newMetrics(),
+			}
+			return d.run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVar(&configPath, "config", "", "Path to the daemon config file")
+	cmd.MarkFlagRequired("config")
+
+	return cmd
+}
+
+// validateCommands checks that every job names a runnable sync subcommand, so
+// typos fail at startup instead of silently printing help on every run.
+func validateCommands(syncCmd *cobra.Command, jobs []Job) error {
+	for _, job := range jobs {
+		found, rest, err := syncCmd.Find(strings.Fields(job.Command))
+		if err != nil || len(rest) > 0 || found == syncCmd || !found.Runnable() {
+			return fmt.Errorf("job %q: unknown sync command %q", job.Name, job.Command)
+		}
+	}
+	return nil
+}
+
+type daemon struct {
+	cfg        *Config
+	executable string
+	slots      chan struct{}
+	metrics    *metrics
+
+	shuttingDown atomic.Bool
+}
+
+type runResult struct {
+	duration time.Duration
+	// resources is the number of upserted resources, or -1 when the job did
+	// not report a count.
+	resources int
+	err       error
+}
+
+func (d *daemon) run(ctx context.Context) error {
+	log.Info("Starting sync daemon", "jobs", len(d.cfg.Jobs), "concurrency", d.cfg.Concurrency)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serverErr := make(chan error, 1)
+	if d.cfg.MetricsAddress != "-" {
+		go func() {
+			serverErr <- serveHTTP(ctx, d.cfg.MetricsAddress, d.metrics, &d.shuttingDown)
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := range d.cfg.Jobs {
+		job := &d.cfg.Jobs[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.loop(ctx, job)
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-serverErr:
+		if err != nil {
+			// Stop the jobs too, so their processes do not outlive the
+			// daemon.
+			err = fmt.Errorf("metrics server failed: %w", err)
+			cancel()
+		}
+		<-ctx.Done()
+	}
+
+	d.shuttingDown.Store(true)
+	log.Info("Shutting down sync daemon, waiting for running jobs")
+	wg.Wait()
+	log.Info("Sync daemon stopped")
+	return err
+}
+
+// loop runs a single job until ctx is cancelled. Failures never stop the
+// loop; they only push the next run out by an exponential backoff.
+func (d *daemon) loop(ctx context.Context, job *Job) {
+	failures := 0
+
+	next := time.Now().Add(cliutil.Jitter(job.Jitter.Std()))
+	if job.Cron != "" {
+		next = job.Next(time.Now()).Add(cliutil.Jitter(job.Jitter.Std()))
+	}
+
+	for {
+		log.Debug("Scheduled sync job", "job", job.Name, "next_run", next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		result := d.runJob(ctx, job)
+		if ctx.Err() != nil && result.err != nil {
+			log.Info("Sync job interrupted by shutdown", "job", job.Name)
+			return
+		}
+
+		now := time.Now()
+		if result.err != nil {
+			failures++
+			delay := cliutil.Backoff(d.cfg.RetryDelay.Std(), d.cfg.MaxBackoff.Std(), failures)
+			next = now.Add(delay)
+			log.Error("Sync job failed",
+				"job", job.Name,
+				"error", result.err,
+				"duration", result.duration,
+				"consecutive_failures", failures,
+				"retry_in", delay,
+			)
+		} else {
+			failures = 0
+			next = job.Next(now).Add(cliutil.Jitter(job.Jitter.Std()))
+			log.Info("Sync job complete",
+				"job", job.Name,
+				"duration", result.duration,
+				"resources", result.resources,
+				"next_run", next,
+			)
+		}
+
+		d.metrics.observe(job.Name, result, failures)
+	}
+}
+
+// runJob executes one run of the job as a `ctrlc sync ...` child process,
+// waiting for a free concurrency slot first.
+func (d *daemon) runJob(ctx context.Context, job *Job) runResult {
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return runResult{resources: -1, err: ctx.Err()}
+	}
+	defer func() { <-d.slots }()
+
+	d.metrics.runningJobs.Inc()
+	defer d.metrics.runningJobs.Dec()
+
+	runCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, job.Timeout.Std())
+		defer cancel()
+	}
+
+	report, err := os.CreateTemp("", "ctrlc-sync-report-*.jsonl")
+	if err != nil {
+		return runResult{resources: -1, err: fmt.Errorf("failed to create report file: %w", err)}
+	}
+	report.Close()
+	defer os.Remove(report.Name())
+
+	args := job.CommandArgs()
+	child := exec.CommandContext(runCtx, d.executable, args...)
+	child.Env = d.jobEnv(job, report.Name())
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Cancel = func() error {
+		return child.Process.Signal(syscall.SIGTERM)
+	}
+	child.WaitDelay = shutdownGracePeriod
+
+	log.Info("Starting sync job", "job", job.Name, "args", args)
+	start := time.Now()
+	err = child.Run()
+	result := runResult{duration: time.Since(start), resources: -1}
+
+	if err != nil {
+		if runCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s: %w", job.Timeout.Std(), err)
+		}
+		result.err = err
+		return result
+	}
+
+	result.resources = readReport(report.Name())
+	return result
+}
+
+// jobEnv forwards the daemon's Ctrlplane connection settings to the child so
+// that flags passed to the daemon apply to every job.
+func (d *daemon) jobEnv(job *Job, reportPath string) []string {
+	env := os.Environ()
+	for key, value := range map[string]string{
+		"CTRLPLANE_URL":       viper.GetString("url"),
+		"CTRLPLANE_API_KEY":   viper.GetString("api-key"),
+		"CTRLPLANE_WORKSPACE": viper.GetString("workspace"),
+		"CTRLPLANE_LOG_LEVEL": viper.GetString("log-level"),
+	} {
+		if value != "" {
+			env = append(env, key+"="+value)
+		}
+	}
+	for key, value := range job.Env {
+		env = append(env, key+"="+value)
+	}
+	return append(env, resourceprovider.SyncReportEnv+"="+reportPath)
+}
+
+// readReport sums the resource counts written by the child, or returns -1
+// when the child did not report any upserts.
+func readReport(path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+
+	total := -1
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var report resourceprovider.SyncReport
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			continue
+		}
+		if total < 0 {
+			total = 0
+		}
+		total += report.Resources
+	}
+	return total
+}
@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestDaemon returns a daemon that runs the given shell script instead of
+// ctrlc for every job.
+func newTestDaemon(t *testing.T, script string, jobs ...Job) *daemon {
+	t.Helper()
+	executable := filepath.Join(t.TempDir(), "ctrlc")
+	if err := os.WriteFile(executable, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return &daemon{
+		cfg: &Config{
+			MetricsAddress: "-",
+			Concurrency:    1,
+			RetryDelay:     Duration(10 * time.Millisecond),
+			MaxBackoff:     Duration(20 * time.Millisecond),
+			Jobs:           jobs,
+		},
+		executable: executable,
+		slots:      make(chan struct{}, 1),
+		metrics:    newMetrics(),
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		script        string
+		job           Job
+		wantResources int
+		wantErr       string
+	}{
+		{
+			name:          "sums the reported resources",
+			script:        `printf '{"provider":"a","resources":2}\n{"provider":"b","resources":3}\n' >> "$CTRLC_SYNC_REPORT_FILE"`,
+			wantResources: 5,
+		},
+		{
+			name:          "an unchanged sync reports zero",
+			script:        `echo '{"provider":"a","resources":0}' >> "$CTRLC_SYNC_REPORT_FILE"`,
+			wantResources: 0,
+		},
+		{
+			name:          "no report",
+			script:        "exit 0",
+			wantResources: -1,
+		},
+		{
+			name:          "job environment",
+			script:        `[ "$REGION" = us-east-1 ] || exit 1`,
+			job:           Job{Env: map[string]string{"REGION": "us-east-1"}},
+			wantResources: -1,
+		},
+		{
+			name:          "failure",
+			script:        `echo '{"provider":"a","resources":2}' >> "$CTRLC_SYNC_REPORT_FILE"; exit 3`,
+			wantResources: -1,
+			wantErr:       "exit status 3",
+		},
+		{
+			name:          "timeout",
+			script:        "exec sleep 5",
+			job:           Job{Timeout: Duration(50 * time.Millisecond)},
+			wantResources: -1,
+			wantErr:       "timed out after 50ms",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			job := tt.job
+			job.Name = "test"
+			d := newTestDaemon(t, tt.script, job)
+
+			result := d.runJob(context.Background(), &job)
+			if tt.wantErr == "" && result.err != nil {
+				t.Fatalf("unexpected error %v", result.err)
+			}
+			if tt.wantErr != "" && (result.err == nil || !strings.Contains(result.err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want %q", result.err, tt.wantErr)
+			}
+			if result.resources != tt.wantResources {
+				t.Errorf("resources = %d, want %d", result.resources, tt.wantResources)
+			}
+			if running := testutil.ToFloat64(d.metrics.runningJobs); running != 0 {
+				t.Errorf("running jobs = %v, want 0", running)
+			}
+		})
+	}
+}
+
+func TestLoop_RetriesWithBackoffThenFollowsSchedule(t *testing.T) {
+	count := filepath.Join(t.TempDir(), "count")
+	// Fails twice, then succeeds with 7 resources.
+	script := `n=$(cat "$COUNT" 2>/dev/null || echo 0); n=$((n+1)); echo $n > "$COUNT"
+[ $n -ge 3 ] || exit 1
+echo '{"provider":"p","resources":7}' >> "$CTRLC_SYNC_REPORT_FILE"`
+	job := Job{Name: "test", Interval: Duration(time.Hour), Env: map[string]string{"COUNT": count}}
+	d := newTestDaemon(t, script, job)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.loop(ctx, &d.cfg.Jobs[0])
+	}()
+
+	// The first run starts right away and the failures are retried after
+	// the short backoff rather than the hourly interval.
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(d.metrics.runs.WithLabelValues("test", "success")) < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the job to succeed after two retries")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// After the success the next run is an hour away.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	data, err := os.ReadFile(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.TrimSpace(string(data)); runs != "3" {
+		t.Errorf("ran %s times, want 3", runs)
+	}
+	if failures := testutil.ToFloat64(d.metrics.errors.WithLabelValues("test")); failures != 2 {
+		t.Errorf("errors = %v, want 2", failures)
+	}
+	if consecutive := testutil.ToFloat64(d.metrics.consecutive.WithLabelValues("test")); consecutive != 0 {
+		t.Errorf("consecutive failures = %v, want 0 after a success", consecutive)
+	}
+	if resources := testutil.ToFloat64(d.metrics.resources.WithLabelValues("test")); resources != 7 {
+		t.Errorf("resources = %v, want 7", resources)
+	}
+}
+
+func TestMetrics_KeepsResourcesWithoutReport(t *testing.T) {
+	m := newMetrics()
+	m.observe("test", runResult{resources: 4}, 0)
+	m.observe("test", runResult{resources: -1}, 0)
+	if resources := testutil.ToFloat64(m.resources.WithLabelValues("test")); resources != 4 {
+		t.Errorf("resources = %v, want 4 from the last reported run", resources)
+	}
+}
+
+func TestRun_MetricsServerFailureStopsJobs(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	job := Job{Name: "test", Interval: Duration(time.Hour)}
+	d := newTestDaemon(t, `trap 'exit 0' TERM; while :; do sleep 0.01; done`, job)
+	d.cfg.MetricsAddress = listener.Addr().String()
+
+	done := make(chan error, 1)
+	go func() { done <- d.run(context.Background()) }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "metrics server failed") {
+			t.Fatalf("error = %v, want a metrics server failure", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the daemon to stop its jobs and exit")
+	}
+	if running := testutil.ToFloat64(d.metrics.runningJobs); running != 0 {
+		t.Errorf("running jobs = %v, want 0 once run returns", running)
+	}
+}
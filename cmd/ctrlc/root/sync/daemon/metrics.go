@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type metrics struct {
+	registry *prometheus.Registry
+
+	lastSuccess  *prometheus.GaugeVec
+	lastDuration *prometheus.GaugeVec
+	duration     *prometheus.HistogramVec
+	resources    *prometheus.GaugeVec
+	runs         *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	consecutive  *prometheus.GaugeVec
+	runningJobs  prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ctrlc_sync_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful run of a sync job.",
+		}, []string{"job"}),
+		lastDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ctrlc_sync_last_duration_seconds",
+			Help: "Duration of the most recent run of a sync job.",
+		}, []string{"job"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ctrlc_sync_run_duration_seconds",
+			Help:    "Duration of sync job runs.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		}, []string{"job", "result"}),
+		resources: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ctrlc_sync_resources",
+			Help: "Number of resources upserted by the last successful run of a sync job.",
+		}, []string{"job"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ctrlc_sync_runs_total",
+			Help: "Total number of sync job runs.",
+		}, []string{"job", "result"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ctrlc_sync_errors_total",
+			Help: "Total number of failed sync job runs.",
+		}, []string{"job"}),
+		consecutive: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ctrlc_sync_consecutive_failures",
+			Help: "Number of consecutive failed runs of a sync job.",
+		}, []string{"job"}),
+		runningJobs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ctrlc_sync_running_jobs",
+			Help: "Number of sync jobs currently running.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.lastSuccess,
+		m.lastDuration,
+		m.duration,
+		m.resources,
+		m.runs,
+		m.errors,
+		m.consecutive,
+		m.runningJobs,
+	)
+
+	return m
+}
+
+func (m *metrics) observe(job string, result runResult, failures int) {
+	seconds := result.duration.Seconds()
+	m.lastDuration.WithLabelValues(job).Set(seconds)
+	m.consecutive.WithLabelValues(job).Set(float64(failures))
+
+	if result.err != nil {
+		m.duration.WithLabelValues(job, "failure").Observe(seconds)
+		m.runs.WithLabelValues(job, "failure").Inc()
+		m.errors.WithLabelValues(job).Inc()
+		return
+	}
+
+	m.duration.WithLabelValues(job, "success").Observe(seconds)
+	m.runs.WithLabelValues(job, "success").Inc()
+	m.lastSuccess.WithLabelValues(job).Set(float64(time.Now().Unix()))
+	if result.resources >= 0 {
+		m.resources.WithLabelValues(job).Set(float64(result.resources))
+	}
+}
+
+// serveHTTP exposes /healthz and /metrics until ctx is cancelled. /healthz
+// reports 503 once shutdown has started so load balancers stop routing.
+func serveHTTP(ctx context.Context, address string, m *metrics, shuttingDown *atomic.Bool) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if shuttingDown.Load() {
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Serving health and metrics", "address", address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/apply"
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/api/providers"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+func NewSyncFilesCmd() *cobra.Command {
+	var providerName string
+	var dirs []string
+	var pattern string
+	var watch bool
+	var debounce time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "files",
+		Short: "Sync resource documents from a directory as one provider set",
+		Long: heredoc.Doc(`
+			Loads every resource document (type: Resource) in the given
+			directories, using the same format and validation as "ctrlc apply",
+			and sets them as the complete resource set of the provider. Unlike
+			apply, resources whose documents are deleted are removed from the
+			provider on the next sync.
+		`),
+		Example: heredoc.Doc(`
+			# Sync a static inventory once
+			$ ctrlc sync files --provider lab --dir inventory/
+
+			# Re-sync whenever a file changes
+			$ ctrlc sync files --provider lab --dir inventory/ --watch
+
+			# inventory/switches.yaml
+			type: Resource
+			name: core-switch-1
+			identifier: lab/core-switch-1
+			kind: Switch
+			version: lab/v1
+			metadata:
+			  rack: r12
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval, _ := cmd.Flags().GetString("interval"); watch && interval != "" {
+				return fmt.Errorf("--watch cannot be combined with --interval")
+			}
+			ctx := cmd.Context()
+			sync := func() error {
+				return syncFiles(ctx, providerName, dirs, pattern)
+			}
+
+			if !watch {
+				return sync()
+			}
+			return watchFiles(ctx, dirs, debounce, sync)
+		},
+	}
+
+	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Resource provider name")
+	cmd.Flags().StringArrayVarP(&dirs, "dir", "d", nil, "Directory containing resource documents (repeatable)")
+	cmd.Flags().StringVar(&pattern, "pattern", "**/*.{yaml,yml}", "Glob, relative to each directory, selecting the files to load")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep running and re-sync when files change")
+	cmd.Flags().DurationVar(&debounce, "debounce", 2*time.Second, "Wait for changes to settle this long before re-syncing in watch mode")
+	cmd.MarkFlagRequired("provider")
+	cmd.MarkFlagRequired("dir")
+
+	return cmd
+}
+
+func syncFiles(ctx context.Context, providerName string, dirs []string, pattern string) error {
+	paths, err := findFiles(dirs, pattern)
+	if err != nil {
+		return err
+	}
+
+	resources, variables, err := loadResources(paths, providerName)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Syncing resources from files", "provider", providerName, "files", len(paths), "count", len(resources))
+	return ctrlp.UpsertResources(ctx, resources, &providerName, ctrlp.WithVariables(variables))
+}
+
+// findFiles returns the files under dirs matching pattern, sorted so that
+// duplicate detection reports a stable first occurrence.
+func findFiles(dirs []string, pattern string) ([]string, error) {
+	var paths []string
+	for _, dir := range dirs {
+		matches, err := doublestar.Glob(os.DirFS(dir), pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			path := filepath.Join(dir, match)
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// loadResources parses the files with the apply parser. Only resource
+// documents are accepted, and documents that name a different provider are
+// rejected since the whole set belongs to providerName.
+func loadResources(paths []string, providerName string) ([]api.ResourceProviderResource, map[string]map[string]any, error) {
+	var resources []api.ResourceProviderResource
+	variables := map[string]map[string]any{}
+	seen := map[string]string{}
+
+	for _, path := range paths {
+		specs, err := apply.ParseFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, typed := range specs {
+			spec, ok := typed.Spec.(*providers.ResourceItemSpec)
+			if !ok {
+				return nil, nil, fmt.Errorf("%s: unsupported document type %q, only Resource documents can be synced", path, typed.Type)
+			}
+			if spec.Provider != "" && spec.Provider != providerName {
+				return nil, nil, fmt.Errorf("%s: resource %q belongs to provider %q, not %q", path, spec.Identifier, spec.Provider, providerName)
+			}
+			if first, ok := seen[spec.Identifier]; ok {
+				return nil, nil, fmt.Errorf("%s: duplicate resource identifier %q, first defined in %s", path, spec.Identifier, first)
+			}
+			seen[spec.Identifier] = path
+
+			config := spec.Config
+			if config == nil {
+				config = map[string]any{}
+			}
+			metadata := spec.Metadata
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+			resources = append(resources, api.ResourceProviderResource{
+				Name:       spec.DisplayName,
+				Identifier: spec.Identifier,
+				Kind:       spec.Kind,
+				Version:    spec.Version,
+				Config:     config,
+				Metadata:   metadata,
+			})
+
+			vars := spec.Variables
+			if vars == nil {
+				vars = map[string]any{}
+			}
+			variables[spec.Identifier] = vars
+		}
+	}
+
+	return resources, variables, nil
+}
+
+// watchFiles runs sync once and then again after every burst of changes under
+// dirs, until ctx is cancelled. Failed syncs are logged and retried on the
+// next change.
+func watchFiles(ctx context.Context, dirs []string, debounce time.Duration, sync func() error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, dir := range dirs {
+		if err := addRecursive(watcher, dir); err != nil {
+			return err
+		}
+	}
+
+	if err := sync(); err != nil {
+		log.Error("Sync failed, waiting for changes", "error", err)
+	}
+
+	log.Info("Watching for changes", "dirs", dirs)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addRecursive(watcher, event.Name); err != nil {
+						log.Warn("Failed to watch new directory", "path", event.Name, "error", err)
+					}
+				}
+			}
+			if event.Has(fsnotify.Chmod) || isHidden(event.Name) {
+				continue
+			}
+			log.Debug("File changed", "path", event.Name, "op", event.Op)
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warn("File watcher error", "error", err)
+		case <-timer.C:
+			if err := sync(); err != nil {
+				log.Error("Sync failed, waiting for changes", "error", err)
+			}
+		}
+	}
+}
+
+func addRecursive(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && isHidden(path) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// isHidden reports dotfiles such as editor swap files and .git, which should
+// not trigger a sync.
+func isHidden(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".")
+}
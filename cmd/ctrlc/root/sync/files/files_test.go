@@ -0,0 +1,97 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadResources_FromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "switches.yaml"), `type: Resource
+name: core-switch-1
+identifier: lab/core-switch-1
+kind: Switch
+version: lab/v1
+variables:
+  vlan: 12
+---
+type: Resource
+name: core-switch-2
+identifier: lab/core-switch-2
+kind: Switch
+version: lab/v1
+`)
+	writeFile(t, filepath.Join(dir, "racks", "r12.yml"), `type: Resource
+name: r12
+identifier: lab/r12
+kind: Rack
+version: lab/v1
+provider: lab
+`)
+	writeFile(t, filepath.Join(dir, "README.md"), "not a resource")
+
+	paths, err := findFiles([]string{dir}, "**/*.{yaml,yml}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 files, got %v", paths)
+	}
+
+	resources, variables, err := loadResources(paths, "lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(resources))
+	}
+	if variables["lab/core-switch-1"]["vlan"] != 12 {
+		t.Fatalf("expected variables to be loaded, got %#v", variables)
+	}
+	if vars, ok := variables["lab/core-switch-2"]; !ok || len(vars) != 0 {
+		t.Fatalf("expected empty variables for resource without any, got %#v", vars)
+	}
+}
+
+func TestLoadResources_RejectsOtherProviderAndDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), `type: Resource
+name: a
+identifier: lab/a
+kind: Server
+version: lab/v1
+provider: other
+`)
+	if _, _, err := loadResources([]string{filepath.Join(dir, "a.yaml")}, "lab"); err == nil || !strings.Contains(err.Error(), `belongs to provider "other"`) {
+		t.Fatalf("expected provider mismatch error, got %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "b.yaml"), `type: Resource
+name: b
+identifier: lab/b
+kind: Server
+version: lab/v1
+`)
+	writeFile(t, filepath.Join(dir, "c.yaml"), `type: Resource
+name: b-again
+identifier: lab/b
+kind: Server
+version: lab/v1
+`)
+	paths := []string{filepath.Join(dir, "b.yaml"), filepath.Join(dir, "c.yaml")}
+	if _, _, err := loadResources(paths, "lab"); err == nil || !strings.Contains(err.Error(), "duplicate resource identifier") {
+		t.Fatalf("expected duplicate identifier error, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package flux
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/ctrlplanedev/cli/internal/kubefilter"
+	"github.com/ctrlplanedev/cli/internal/kubeobject"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// The served versions of each kind, newest first. Older Flux installations
+// only serve the beta versions.
+var (
+	kustomizations = []schema.GroupVersionResource{
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Resource: "kustomizations"},
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1beta2", Resource: "kustomizations"},
+	}
+	helmReleases = []schema.GroupVersionResource{
+		{Group: "helm.toolkit.fluxcd.io", Version: "v2", Resource: "helmreleases"},
+		{Group: "helm.toolkit.fluxcd.io", Version: "v2beta2", Resource: "helmreleases"},
+		{Group: "helm.toolkit.fluxcd.io", Version: "v2beta1", Resource: "helmreleases"},
+	}
+)
+
+type options struct {
+	providerName string
+	clusterName  string
+	filter       kubefilter.Filter
+}
+
+func NewSyncFluxCmd() *cobra.Command {
+	var opts options
+
+	cmd := &cobra.Command{
+		Use:   "flux",
+		Short: "Sync Flux Kustomizations and HelmReleases into Ctrlplane",
+		Long: heredoc.Doc(`
+			Reads the kustomize.toolkit.fluxcd.io Kustomizations and
+			helm.toolkit.fluxcd.io HelmReleases of the current kubeconfig
+			context and upserts them with their source, last applied revision,
+			Ready condition and suspend flag as metadata.
+
+			Unlike "ctrlc sync helm" this does not depend on Helm's release
+			storage, so it also covers HelmReleases that store their state
+			elsewhere or have not been installed yet.
+		`),
+		Example: heredoc.Doc(`
+			$ ctrlc sync flux
+			$ ctrlc sync flux --namespace flux-system --cluster-name prod
+			$ ctrlc sync flux --label-selector team=payments --interval 5m
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.providerName, "provider", "p", "", "Name of the resource provider (default is flux-<cluster name>)")
+	cmd.Flags().StringVarP(&opts.clusterName, "cluster-name", "n", "", "The name of the cluster (default is the kubeconfig cluster)")
+	opts.filter.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *options) run(ctx context.Context) error {
+	return kubeobject.Sync(ctx, "flux", o.providerName, o.clusterName,
+		func(ctx context.Context, client dynamic.Interface, clusterName string) ([]api.ResourceProviderResource, error) {
+			log.Info("Syncing Flux resources", "cluster", clusterName)
+			run := *o
+			run.clusterName = clusterName
+			return run.fetch(ctx, client)
+		}, ctrlp.WithRelationshipRules(relationshipRules...))
+}
+
+// fetch lists the Kustomizations and HelmReleases that pass the filter. A
+// controller that is not installed yields no resources.
+func (o *options) fetch(ctx context.Context, client dynamic.Interface) ([]api.ResourceProviderResource, error) {
+	ks, err := o.list(ctx, client, kustomizations)
+	if err != nil {
+		return nil, err
+	}
+	hrs, err := o.list(ctx, client, helmReleases)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]api.ResourceProviderResource, 0, len(ks)+len(hrs))
+	for _, k := range ks {
+		resources = append(resources, kustomizationToResource(k, o.clusterName))
+	}
+	for _, hr := range hrs {
+		resources = append(resources, helmReleaseToResource(hr, o.clusterName))
+	}
+	log.Info("Found Flux resources", "kustomizations", len(ks), "helmReleases", len(hrs))
+	return resources, nil
+}
+
+// list lists a kind in every namespace of the filter, using the newest
+// version the cluster serves.
+func (o *options) list(ctx context.Context, client dynamic.Interface, versions []schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	for _, gvr := range versions {
+		items, err := o.filter.List(ctx, client, gvr)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		return items, err
+	}
+	log.Debug("Flux CRD not installed, skipping", "resource", versions[0].GroupResource())
+	return nil, nil
+}
+
+func kustomizationToResource(k unstructured.Unstructured, clusterName string) api.ResourceProviderResource {
+	identifier := fmt.Sprintf("%s/%s/%s", clusterName, k.GetNamespace(), k.GetName())
+	str := func(fields ...string) string {
+		value, _, _ := unstructured.NestedString(k.Object, fields...)
+		return value
+	}
+	sourceRef, _, _ := unstructured.NestedMap(k.Object, "spec", "sourceRef")
+	targetNamespace := str("spec", "targetNamespace")
+
+	metadata := baseMetadata(k, clusterName)
+	addSourceRef(metadata, sourceRef, k.GetNamespace())
+	metadata["flux/path"] = str("spec", "path")
+	metadata["flux/target-namespace"] = targetNamespace
+	metadata["kubernetes/namespace"] = targetNamespace
+	metadata["flux/last-applied-revision"] = str("status", "lastAppliedRevision")
+	metadata["flux/last-attempted-revision"] = str("status", "lastAttemptedRevision")
+	metadata["ctrlplane/version"] = fmt.Sprintf("rev:%s ready:%s",
+		kubeobject.ShortRevision(metadata["flux/last-applied-revision"]), metadata["flux/ready"])
+	kubeobject.DropEmpty(metadata)
+
+	return api.ResourceProviderResource{
+		Version:    "ctrlplane.dev/flux/kustomization/v1",
+		Kind:       "FluxKustomization",
+		Name:       identifier, // Use cluster/namespace/name for uniqueness
+		Identifier: identifier,
+		Config: map[string]any{
+			"name":                k.GetName(),
+			"namespace":           k.GetNamespace(),
+			"path":                str("spec", "path"),
+			"targetNamespace":     targetNamespace,
+			"sourceRef":           sourceRef,
+			"lastAppliedRevision": str("status", "lastAppliedRevision"),
+			"suspended":           metadata["flux/suspended"] == "true",
+			"ready":               metadata["flux/ready"],
+		},
+		Metadata: metadata,
+	}
+}
+
+func helmReleaseToResource(hr unstructured.Unstructured, clusterName string) api.ResourceProviderResource {
+	identifier := fmt.Sprintf("%s/%s/%s", clusterName, hr.GetNamespace(), hr.GetName())
+	str := func(fields ...string) string {
+		value, _, _ := unstructured.NestedString(hr.Object, fields...)
+		return value
+	}
+
+	// Charts come from a HelmRepository, GitRepository or Bucket through
+	// spec.chart, or from an OCIRepository or HelmChart through spec.chartRef
+	sourceRef, _, _ := unstructured.NestedMap(hr.Object, "spec", "chart", "spec", "sourceRef")
+	if sourceRef == nil {
+		sourceRef, _, _ = unstructured.NestedMap(hr.Object, "spec", "chartRef")
+	}
+
+	targetNamespace := str("spec", "targetNamespace")
+	if targetNamespace == "" {
+		targetNamespace = hr.GetNamespace()
+	}
+	releaseName := str("spec", "releaseName")
+	if releaseName == "" {
+		releaseName = hr.GetName()
+		if namespace := str("spec", "targetNamespace"); namespace != "" {
+			releaseName = namespace + "-" + releaseName
+		}
+	}
+
+	// v2 records the deployed chart in status.history, newest first; the
+	// beta versions in status.lastAppliedRevision
+	revision := str("status", "lastAppliedRevision")
+	appVersion := ""
+	if history, _, _ := unstructured.NestedSlice(hr.Object, "status", "history"); len(history) > 0 {
+		if latest, ok := history[0].(map[string]any); ok {
+			if version, _ := latest["chartVersion"].(string); revision == "" {
+				revision = version
+			}
+			appVersion, _ = latest["appVersion"].(string)
+		}
+	}
+
+	metadata := baseMetadata(hr, clusterName)
+	addSourceRef(metadata, sourceRef, hr.GetNamespace())
+	metadata["flux/chart-name"] = str("spec", "chart", "spec", "chart")
+	metadata["flux/chart-version"] = str("spec", "chart", "spec", "version")
+	metadata["flux/release-name"] = releaseName
+	metadata["flux/target-namespace"] = targetNamespace
+	metadata["kubernetes/namespace"] = targetNamespace
+	metadata["flux/last-applied-revision"] = revision
+	metadata["flux/last-attempted-revision"] = str("status", "lastAttemptedRevision")
+	metadata["flux/app-version"] = appVersion
+	metadata["ctrlplane/version"] = fmt.Sprintf("rev:%s ready:%s", kubeobject.ShortRevision(revision), metadata["flux/ready"])
+	kubeobject.DropEmpty(metadata)
+
+	return api.ResourceProviderResource{
+		Version:    "ctrlplane.dev/flux/helm-release/v1",
+		Kind:       "FluxHelmRelease",
+		Name:       identifier,
+		Identifier: identifier,
+		Config: map[string]any{
+			"name":                hr.GetName(),
+			"namespace":           hr.GetNamespace(),
+			"releaseName":         releaseName,
+			"targetNamespace":     targetNamespace,
+			"chart":               metadata["flux/chart-name"],
+			"chartVersion":        metadata["flux/chart-version"],
+			"sourceRef":           sourceRef,
+			"lastAppliedRevision": revision,
+			"suspended":           metadata["flux/suspended"] == "true",
+			"ready":               metadata["flux/ready"],
+		},
+		Metadata: metadata,
+	}
+}
+
+// baseMetadata holds the labels, location, suspend flag and Ready condition
+// shared by both kinds.
+func baseMetadata(object unstructured.Unstructured, clusterName string) map[string]string {
+	metadata := kubeobject.Metadata(object, "flux", clusterName)
+	metadata["kubernetes/name"] = clusterName
+
+	suspended, _, _ := unstructured.NestedBool(object.Object, "spec", "suspend")
+	metadata["flux/suspended"] = strconv.FormatBool(suspended)
+
+	metadata["flux/ready"] = "unknown"
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, ok := condition.(map[string]any)
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		metadata["flux/ready"] = strings.ToLower(status)
+		metadata["flux/ready-reason"] = reason
+	}
+	return metadata
+}
+
+// addSourceRef records where the manifests or chart come from. The source
+// namespace defaults to the namespace of the object.
+func addSourceRef(metadata map[string]string, sourceRef map[string]any, namespace string) {
+	if sourceRef == nil {
+		return
+	}
+	kind, _ := sourceRef["kind"].(string)
+	name, _ := sourceRef["name"].(string)
+	if sourceNamespace, _ := sourceRef["namespace"].(string); sourceNamespace != "" {
+		namespace = sourceNamespace
+	}
+	metadata["flux/source-kind"] = kind
+	metadata["flux/source-name"] = name
+	metadata["flux/source-namespace"] = namespace
+	metadata["flux/source"] = fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// relationshipRules links HelmReleases to the release synced by
+// `ctrlc sync helm` on the same cluster.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "helm-release",
+		Name:      "Flux Helm Release",
+		Cel: `from.version == "ctrlplane.dev/flux/helm-release/v1" &&
+			to.version == "ctrlplane.dev/helm/release/v1" &&
+			from.metadata["kubernetes/name"] == to.metadata["kubernetes/name"] &&
+			from.metadata["flux/target-namespace"] == to.metadata["helm/namespace"] &&
+			from.metadata["flux/release-name"] == to.metadata["helm/name"]`,
+	},
+}
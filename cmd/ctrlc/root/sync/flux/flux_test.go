@@ -0,0 +1,98 @@
+package flux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/kubeobject/kubeobjecttest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestFetch(t *testing.T) {
+	apps := kubeobjecttest.NewObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "apps", map[string]any{
+		"spec": map[string]any{
+			"path":      "./apps/prod",
+			"suspend":   true,
+			"sourceRef": map[string]any{"kind": "GitRepository", "name": "fleet"},
+		},
+		"status": map[string]any{
+			"lastAppliedRevision": "main@sha1:4773b9f1f02b5b6c5e0b5b2f1e5dbb6e7a3d1c2b",
+			"conditions": []any{
+				map[string]any{"type": "Reconciling", "status": "False"},
+				map[string]any{"type": "Ready", "status": "True", "reason": "ReconciliationSucceeded"},
+			},
+		},
+	})
+	podinfo := kubeobjecttest.NewObject("helm.toolkit.fluxcd.io/v2beta2", "HelmRelease", "flux-system", "podinfo", map[string]any{
+		"spec": map[string]any{
+			"targetNamespace": "web",
+			"chart": map[string]any{"spec": map[string]any{
+				"chart":     "podinfo",
+				"version":   "6.x",
+				"sourceRef": map[string]any{"kind": "HelmRepository", "name": "podinfo", "namespace": "sources"},
+			}},
+		},
+		"status": map[string]any{
+			"lastAppliedRevision": "6.5.4",
+			"conditions": []any{
+				map[string]any{"type": "Ready", "status": "False", "reason": "InstallFailed"},
+			},
+		},
+	})
+
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		kustomizations[0]: "KustomizationList",
+		helmReleases[0]:   "HelmReleaseList",
+		helmReleases[1]:   "HelmReleaseList",
+	}, apps, podinfo)
+	// Only v2beta2 HelmReleases are served, as on Flux 2.2
+	client.PrependReactor("list", "helmreleases", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource() == helmReleases[0] {
+			return true, nil, apierrors.NewNotFound(helmReleases[0].GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+
+	o := options{clusterName: "prod"}
+	resources, err := o.fetch(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byIdentifier := map[string]api.ResourceProviderResource{}
+	for _, resource := range resources {
+		byIdentifier[resource.Identifier] = resource
+	}
+
+	kustomization := byIdentifier["prod/flux-system/apps"].Metadata
+	for key, value := range map[string]string{
+		"flux/source":                "GitRepository/flux-system/fleet",
+		"flux/last-applied-revision": "main@sha1:4773b9f1f02b5b6c5e0b5b2f1e5dbb6e7a3d1c2b",
+		"flux/ready":                 "true",
+		"flux/suspended":             "true",
+		"ctrlplane/version":          "rev:main@sha1:4773b9f ready:true",
+	} {
+		if kustomization[key] != value {
+			t.Errorf("kustomization %s = %q, want %q", key, kustomization[key], value)
+		}
+	}
+
+	release := byIdentifier["prod/flux-system/podinfo"].Metadata
+	for key, value := range map[string]string{
+		"flux/source":                "HelmRepository/sources/podinfo",
+		"flux/chart-name":            "podinfo",
+		"flux/release-name":          "web-podinfo",
+		"flux/last-applied-revision": "6.5.4",
+		"flux/ready":                 "false",
+		"flux/ready-reason":          "InstallFailed",
+		"flux/suspended":             "false",
+	} {
+		if release[key] != value {
+			t.Errorf("helm release %s = %q, want %q", key, release[key], value)
+		}
+	}
+}
@@ -123,7 +123,7 @@ func runSync(repoPath, token, name *string, states *[]string) func(cmd *cobra.Co
 			"repoPath", *repoPath,
 			"states", *states)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Get token from flag or environment
 		githubToken := *token
@@ -72,7 +72,7 @@ func runSync(project, name *string) func(cmd *cobra.Command, args []string) erro
 	return func(cmd *cobra.Command, args []string) error {
 		log.Info("Syncing Bigtable instances into Ctrlplane", "project", *project)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize clients
 		adminClient, err := initBigtableClient(ctx)
@@ -63,7 +63,7 @@ func runSync(project, name *string) func(cmd *cobra.Command, args []string) erro
 	return func(cmd *cobra.Command, args []string) error {
 		log.Info("Syncing Storage buckets into Ctrlplane", "project", *project)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize clients
 		storageClient, err := initStorageClient(ctx)
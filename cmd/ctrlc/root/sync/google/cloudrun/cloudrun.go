@@ -103,7 +103,7 @@ func runSync(project, providerName *string, regions *[]string) func(cmd *cobra.C
 	return func(cmd *cobra.Command, args []string) error {
 		log.Info("Syncing Cloud Run services into Ctrlplane", "project", *project)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		cloudRunService, err := initCloudRunClient(ctx)
 		if err != nil {
@@ -205,7 +205,7 @@ func runSync(project, providerName *string) func(cmd *cobra.Command, args []stri
 	return func(cmd *cobra.Command, args []string) error {
 		log.Info("Syncing Cloud SQL instances into Ctrlplane", "project", *project)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize SQL Admin client
 		sqlService, err := initSQLAdminClient(ctx)
@@ -225,7 +225,10 @@ func runSync(project, providerName *string) func(cmd *cobra.Command, args []stri
 		}
 
 		// Upsert resources to Ctrlplane
-		return ctrlp.UpsertResources(ctx, resources, providerName)
+		return ctrlp.UpsertResources(ctx, resources, providerName,
+			ctrlp.WithRelationshipRules(relationshipRules...),
+			ctrlp.WithVariables(ctrlp.DatabaseVariables(resources)),
+		)
 	}
 }
 
@@ -376,20 +379,17 @@ func buildInstanceMetadata(instance *sqladmin.DatabaseInstance, project, region,
 	return metadata
 }
 
-// var relationshipRules = []api.ResourceProviderResourceRelationshipRule{
-// 	{
-// 		Reference:      "network",
-// 		Name:           "Google Cloud SQL Network",
-// 		DependencyType: api.ProvisionedIn,
-//
-// 		SourceVersion: "ctrlplane.dev/database/v1",
-// 		SourceKind:    "GoogleCloudSQL",
-// 		TargetVersion: "ctrlplane.dev/network/v1",
-// 		TargetKind:    "GoogleNetwork",
-//
-// 		MetadataKeysMatches: &[]api.MetadataKeyMatchConstraint{
-// 			{SourceKey: "google/project", TargetKey: "google/project"},
-// 			{SourceKey: "network/id", TargetKey: "network/id"},
-// 		},
-// 	},
-// }
+// relationshipRules links Cloud SQL instances to the VPC they are peered
+// with, as synced by `ctrlc sync google networks`.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "network",
+		Name:      "Google Cloud SQL Network",
+		Cel: `from.version == "ctrlplane.dev/database/v1" &&
+			from.kind == "GoogleCloudSQL" &&
+			to.version == "ctrlplane.dev/network/v1" &&
+			to.kind == "GoogleNetwork" &&
+			from.metadata["google/project"] == to.metadata["google/project"] &&
+			from.metadata["network/name"] == to.metadata["network/name"]`,
+	},
+}
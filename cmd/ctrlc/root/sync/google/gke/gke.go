@@ -10,9 +10,11 @@ import (
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/Masterminds/semver"
 	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/kubernetes"
 	"github.com/ctrlplanedev/cli/internal/api"
 	ctrlp "github.com/ctrlplanedev/cli/internal/common"
 	"github.com/ctrlplanedev/cli/internal/kinds"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
 	"github.com/spf13/cobra"
 	"google.golang.org/api/container/v1"
 )
@@ -21,6 +23,7 @@ import (
 func NewSyncGKECmd() *cobra.Command {
 	var project string
 	var name string
+	var workloads kubernetes.WorkloadOptions
 
 	cmd := &cobra.Command{
 		Use:   "gke",
@@ -30,15 +33,19 @@ func NewSyncGKECmd() *cobra.Command {
 			
 			# Sync all GKE clusters from a project
 			$ ctrlc sync google-cloud gke --project my-project
+
+			# Also sync the namespaces, deployments, nodes and Helm releases in every cluster
+			$ ctrlc sync google-cloud gke --project my-project --with-workloads --with-helm
 		`),
 		PreRunE: validateFlags(&project),
-		RunE:    runSync(&project, &name),
+		RunE:    runSync(&project, &name, &workloads),
 	}
 
 	// Add command flags
 	cmd.Flags().StringVarP(&name, "provider", "p", "", "Name of the resource provider")
 	cmd.Flags().StringVarP(&project, "project", "c", "", "Google Cloud Project ID")
 	cmd.MarkFlagRequired("project")
+	workloads.AddFlags(cmd.Flags())
 
 	return cmd
 }
@@ -54,11 +61,11 @@ func validateFlags(project *string) func(cmd *cobra.Command, args []string) erro
 }
 
 // runSync contains the main sync logic
-func runSync(project, name *string) func(cmd *cobra.Command, args []string) error {
+func runSync(project, name *string, workloads *kubernetes.WorkloadOptions) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		log.Info("Syncing GKE clusters into Ctrlplane", "project", *project)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize clients
 		gkeClient, err := initGKEClient(ctx)
@@ -67,7 +74,7 @@ func runSync(project, name *string) func(cmd *cobra.Command, args []string) erro
 		}
 
 		// List and process clusters
-		resources, err := processClusters(ctx, gkeClient, *project)
+		resources, clusters, err := processClusters(ctx, gkeClient, *project, workloads.Enabled)
 		if err != nil {
 			return err
 		}
@@ -78,7 +85,14 @@ func runSync(project, name *string) func(cmd *cobra.Command, args []string) erro
 		}
 
 		// Upsert resources to Ctrlplane
-		return ctrlp.UpsertResources(ctx, resources, name)
+		if err := ctrlp.UpsertResources(ctx, resources, name,
+			ctrlp.WithRelationshipRules(relationshipRules...),
+			ctrlp.WithVariables(ctrlp.ClusterVariables(resources)),
+		); err != nil {
+			return err
+		}
+
+		return kubernetes.SyncWorkloads(ctx, *workloads, clusters)
 	}
 }
 
@@ -91,17 +105,19 @@ func initGKEClient(ctx context.Context) (*container.Service, error) {
 	return gkeClient, nil
 }
 
-// processClusters lists and processes all GKE clusters
-func processClusters(ctx context.Context, gkeClient *container.Service, project string) ([]api.ResourceProviderResource, error) {
+// processClusters lists and processes all GKE clusters. With workloads it
+// also returns the credentials to reach every running cluster.
+func processClusters(ctx context.Context, gkeClient *container.Service, project string, workloads bool) ([]api.ResourceProviderResource, []kubernetes.Cluster, error) {
 	parent := fmt.Sprintf("projects/%s/locations/-", project)
 	resp, err := gkeClient.Projects.Locations.Clusters.List(parent).Do()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list GKE clusters: %w", err)
+		return nil, nil, fmt.Errorf("failed to list GKE clusters: %w", err)
 	}
 
 	log.Info("Found GKE clusters", "count", len(resp.Clusters))
 
 	resources := []api.ResourceProviderResource{}
+	var clusters []kubernetes.Cluster
 	for _, cluster := range resp.Clusters {
 		resource, err := processCluster(ctx, cluster, project)
 		if err != nil {
@@ -109,9 +125,18 @@ func processClusters(ctx context.Context, gkeClient *container.Service, project
 			continue
 		}
 		resources = append(resources, resource)
+
+		if workloads && cluster.Status == "RUNNING" {
+			workload, err := workloadCluster(ctx, cluster, project)
+			if err != nil {
+				log.Error("Failed to get GKE cluster credentials", "name", cluster.Name, "error", err)
+				continue
+			}
+			clusters = append(clusters, workload)
+		}
 	}
 
-	return resources, nil
+	return resources, clusters, nil
 }
 
 // processCluster handles processing of a single GKE cluster
@@ -223,6 +248,7 @@ func initClusterMetadata(cluster *container.Cluster, project string) map[string]
 		"kubernetes/location":        cluster.Location,
 		"kubernetes/location-type":   locationType,
 		"kubernetes/endpoint":        cluster.Endpoint,
+		kinds.K8SMetadataServerHost:  kubeconfig.ServerHost(cluster.Endpoint),
 		"kubernetes/node-pool-count": strconv.Itoa(len(cluster.NodePools)),
 
 		"google/project":       project,
@@ -387,21 +413,26 @@ func getResourceName(fullPath string) string {
 	return parts[len(parts)-1]
 }
 
-// var relationshipRules = []api.ResourceProviderResourceRelationshipRule{
-// 	{
-// 		Reference:      "network",
-// 		Name:           "Google Cloud Cluster Network",
-// 		DependencyType: api.ProvisionedIn,
-//
-// 		SourceVersion: "ctrlplane.dev/kubernetes/cluster/v1",
-// 		SourceKind:    "GoogleKubernetesEngine",
-//
-// 		TargetVersion: "ctrlplane.dev/network/v1",
-// 		TargetKind:    "GoogleNetwork",
-//
-// 		MetadataKeysMatches: &[]api.MetadataKeyMatchConstraint{
-// 			{SourceKey: "google/project", TargetKey: "google/project"},
-// 			{SourceKey: "network/id", TargetKey: "network/id"},
-// 		},
-// 	},
-// }
+// relationshipRules links GKE clusters to their VPC, and Kubernetes nodes
+// synced by `ctrlc sync kubernetes` to the cluster owning their node pool.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "network",
+		Name:      "Google Cloud Cluster Network",
+		Cel: `from.version == "ctrlplane.dev/kubernetes/cluster/v1" &&
+			from.kind == "GoogleKubernetesEngine" &&
+			to.version == "ctrlplane.dev/network/v1" &&
+			to.kind == "GoogleNetwork" &&
+			from.metadata["google/project"] == to.metadata["google/project"] &&
+			from.metadata["network/vpc"] == to.metadata["network/name"]`,
+	},
+	{
+		Reference: "cluster",
+		Name:      "Google Cloud Node Pool Cluster",
+		Cel: `from.kind == "KubernetesNode" &&
+			"tags/cloud.google.com/gke-nodepool" in from.metadata &&
+			to.kind == "GoogleKubernetesEngine" &&
+			from.metadata["kubernetes/name"] == to.name &&
+			("kubernetes/node-pool/" + from.metadata["tags/cloud.google.com/gke-nodepool"] + "/name") in to.metadata`,
+	},
+}
@@ -0,0 +1,48 @@
+package gke
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/kubernetes"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/container/v1"
+	"k8s.io/client-go/rest"
+)
+
+// workloadCluster builds the client configuration for a cluster from the
+// application default credentials, which GKE accepts as bearer tokens.
+func workloadCluster(ctx context.Context, cluster *container.Cluster, project string) (kubernetes.Cluster, error) {
+	if cluster.MasterAuth == nil || cluster.MasterAuth.ClusterCaCertificate == "" {
+		return kubernetes.Cluster{}, fmt.Errorf("cluster has no certificate authority")
+	}
+	ca, err := base64.StdEncoding.DecodeString(cluster.MasterAuth.ClusterCaCertificate)
+	if err != nil {
+		return kubernetes.Cluster{}, fmt.Errorf("failed to decode certificate authority: %w", err)
+	}
+	tokens, err := google.DefaultTokenSource(ctx, container.CloudPlatformScope)
+	if err != nil {
+		return kubernetes.Cluster{}, fmt.Errorf("failed to get Google Cloud credentials: %w", err)
+	}
+
+	host := cluster.Endpoint
+	if !strings.HasPrefix(host, "https://") {
+		host = "https://" + host
+	}
+	return kubernetes.Cluster{
+		Identifier: cluster.SelfLink,
+		Name:       cluster.Name,
+		Provider:   fmt.Sprintf("google-gke-%s-%s-%s", project, cluster.Location, cluster.Name),
+		Config: &rest.Config{
+			Host:            host,
+			TLSClientConfig: rest.TLSClientConfig{CAData: ca},
+			WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+				return &oauth2.Transport{Source: tokens, Base: rt}
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,65 @@
+package gke
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/api/container/v1"
+)
+
+// useTestCredentials points the application default credentials at a user
+// credential file, which is only exchanged for a token on the first request.
+func useTestCredentials(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	credentials := `{"type":"authorized_user","client_id":"id","client_secret":"secret","refresh_token":"refresh"}`
+	if err := os.WriteFile(path, []byte(credentials), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+}
+
+func TestWorkloadCluster(t *testing.T) {
+	useTestCredentials(t)
+	cluster := &container.Cluster{
+		Name:       "prod",
+		Location:   "us-central1",
+		SelfLink:   "https://container.googleapis.com/v1/projects/acme/locations/us-central1/clusters/prod",
+		Endpoint:   "34.1.2.3",
+		MasterAuth: &container.MasterAuth{ClusterCaCertificate: base64.StdEncoding.EncodeToString([]byte("ca"))},
+	}
+
+	workload, err := workloadCluster(context.Background(), cluster, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workload.Identifier != cluster.SelfLink || workload.Provider != "google-gke-acme-us-central1-prod" {
+		t.Errorf("unexpected cluster %+v", workload)
+	}
+	if workload.Config.Host != "https://34.1.2.3" {
+		t.Errorf("host = %q, want https://34.1.2.3", workload.Config.Host)
+	}
+	if string(workload.Config.CAData) != "ca" {
+		t.Errorf("CA = %q, want the decoded certificate", workload.Config.CAData)
+	}
+	if workload.Config.WrapTransport == nil {
+		t.Error("expected requests to be authenticated with the default credentials")
+	}
+}
+
+func TestWorkloadCluster_InvalidCA(t *testing.T) {
+	useTestCredentials(t)
+	for name, auth := range map[string]*container.MasterAuth{
+		"missing":    nil,
+		"empty":      {},
+		"not base64": {ClusterCaCertificate: "%%%"},
+	} {
+		cluster := &container.Cluster{Name: "prod", Endpoint: "https://34.1.2.3", MasterAuth: auth}
+		if _, err := workloadCluster(context.Background(), cluster, "acme"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
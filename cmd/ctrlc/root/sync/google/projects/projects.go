@@ -1,7 +1,6 @@
 package projects
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/MakeNowJust/heredoc/v2"
@@ -29,7 +28,7 @@ func NewSyncProjectsCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Info("Syncing Google Cloud projects into Ctrlplane")
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			// Create Cloud Resource Manager client
 			crm, err := cloudresourcemanager.NewService(ctx)
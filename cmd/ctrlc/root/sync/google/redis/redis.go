@@ -57,7 +57,7 @@ func runSync(project, name *string) func(cmd *cobra.Command, args []string) erro
 	return func(cmd *cobra.Command, args []string) error {
 		log.Info("Syncing Redis instances into Ctrlplane", "project", *project)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize clients
 		redisClient, err := initRedisClient(ctx)
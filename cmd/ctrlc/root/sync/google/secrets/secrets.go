@@ -56,7 +56,7 @@ func runSync(project, name *string) func(cmd *cobra.Command, args []string) erro
 	return func(cmd *cobra.Command, args []string) error {
 		log.Info("Syncing Google Secret Manager secrets into Ctrlplane", "project", *project)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Initialize Secret Manager client
 		secretClient, err := initSecretManagerClient(ctx)
@@ -0,0 +1,153 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/pflag"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+)
+
+// ReleaseOptions select the optional parts of a release resource. They are
+// off by default because they read the release values and manifest, which
+// can be large.
+type ReleaseOptions struct {
+	// ValuesDigest adds a hash of the user-supplied values.
+	ValuesDigest bool
+	// ValuesPaths are dotted paths, such as image.tag, copied from the
+	// effective values into config.values.
+	ValuesPaths []string
+	// History is the number of latest revisions to record.
+	History int
+	// ManifestObjects counts the objects of the manifest by kind.
+	ManifestObjects bool
+}
+
+func (o *ReleaseOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.ValuesDigest, "values-digest", false, "Record a sha256 digest of the user-supplied values as helm/values-digest")
+	flags.StringSliceVar(&o.ValuesPaths, "values-path", nil, "Copy this dotted path of the effective values, e.g. image.tag, into config.values (repeatable)")
+	flags.IntVar(&o.History, "history", 0, "Record the statuses of the last N revisions")
+	flags.BoolVar(&o.ManifestObjects, "manifest-objects", false, "Record the number of objects of each kind in the release manifest")
+}
+
+// addReleaseDetails adds the parts selected by opts to a release resource.
+// history holds the revisions of the release, newest first.
+func addReleaseDetails(rel *release.Release, history []*release.Release, opts ReleaseOptions, config map[string]any, metadata map[string]string) {
+	if opts.ValuesDigest {
+		if digest, err := valuesDigest(rel.Config); err == nil {
+			metadata["helm/values-digest"] = digest
+			config["valuesDigest"] = digest
+		} else {
+			log.Warn("Failed to hash Helm values", "release", rel.Name, "error", err)
+		}
+	}
+
+	if len(opts.ValuesPaths) > 0 {
+		values, err := chartutil.CoalesceValues(rel.Chart, rel.Config)
+		if err != nil {
+			log.Warn("Failed to compute Helm values", "release", rel.Name, "error", err)
+		} else {
+			selected := map[string]any{}
+			for _, path := range opts.ValuesPaths {
+				if value, ok := lookupValue(values, path); ok {
+					selected[path] = value
+				}
+			}
+			config["values"] = selected
+		}
+	}
+
+	if opts.History > 0 && len(history) > 0 {
+		if len(history) > opts.History {
+			history = history[:opts.History]
+		}
+		revisions := make([]map[string]any, 0, len(history))
+		statuses := make([]string, 0, len(history))
+		for _, revision := range history {
+			entry := map[string]any{
+				"revision":     revision.Version,
+				"status":       revision.Info.Status.String(),
+				"chartVersion": revision.Chart.Metadata.Version,
+				"appVersion":   revision.Chart.Metadata.AppVersion,
+				"description":  revision.Info.Description,
+			}
+			if !revision.Info.LastDeployed.IsZero() {
+				entry["updated"] = revision.Info.LastDeployed.Format(time.RFC3339)
+			}
+			revisions = append(revisions, entry)
+			statuses = append(statuses, fmt.Sprintf("%d:%s", revision.Version, revision.Info.Status))
+		}
+		config["history"] = revisions
+		metadata["helm/history"] = strings.Join(statuses, ",")
+	}
+
+	if opts.ManifestObjects {
+		counts := manifestObjects(rel.Manifest)
+		total := 0
+		for kind, count := range counts {
+			metadata["helm/objects/"+kind] = strconv.Itoa(count)
+			total += count
+		}
+		metadata["helm/object-count"] = strconv.Itoa(total)
+		config["objects"] = counts
+	}
+}
+
+// valuesDigest hashes the user-supplied values. Map keys are marshalled in
+// sorted order, so equal values always have the same digest.
+func valuesDigest(values map[string]any) (string, error) {
+	if values == nil {
+		values = map[string]any{}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// lookupValue returns the value at a dotted path, which may be a table.
+func lookupValue(values map[string]any, path string) (any, bool) {
+	var current any = values
+	for _, key := range strings.Split(path, ".") {
+		var table map[string]any
+		switch t := current.(type) {
+		case map[string]any:
+			table = t
+		case chartutil.Values:
+			table = t
+		default:
+			return nil, false
+		}
+		var ok bool
+		if current, ok = table[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// manifestObjects counts the objects of a rendered manifest by kind.
+func manifestObjects(manifest string) map[string]int {
+	counts := map[string]int{}
+	for _, document := range releaseutil.SplitManifests(manifest) {
+		var head struct {
+			Kind string `json:"kind"`
+		}
+		if err := yaml.Unmarshal([]byte(document), &head); err != nil || head.Kind == "" {
+			continue
+		}
+		counts[head.Kind]++
+	}
+	return counts
+}
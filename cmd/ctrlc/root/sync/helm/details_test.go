@@ -0,0 +1,63 @@
+package helm
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestHelmReleaseToResource_Details(t *testing.T) {
+	revision := func(version int, status release.Status, values map[string]any) *release.Release {
+		return &release.Release{
+			Name:      "web",
+			Namespace: "prod",
+			Version:   version,
+			Info:      &release.Info{Status: status},
+			Chart: &chart.Chart{
+				Metadata: &chart.Metadata{Name: "web", Version: "1.2.0", AppVersion: "2.0"},
+				Values:   map[string]any{"replicaCount": 1, "image": map[string]any{"repository": "web", "tag": "latest"}},
+			},
+			Config: values,
+			Manifest: `---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+---
+# Source: web/templates/worker.yaml
+apiVersion: apps/v1
+kind: Deployment
+`,
+		}
+	}
+	current := revision(3, release.StatusDeployed, map[string]any{"image": map[string]any{"tag": "v3"}})
+	history := []*release.Release{current, revision(2, release.StatusFailed, nil), revision(1, release.StatusSuperseded, nil)}
+
+	resource := helmReleaseToResource(current, history, "cluster-a", ReleaseOptions{
+		ValuesDigest:    true,
+		ValuesPaths:     []string{"image.tag", "replicaCount", "missing"},
+		History:         2,
+		ManifestObjects: true,
+	})
+
+	wantValues := map[string]any{"image.tag": "v3", "replicaCount": 1}
+	if got := resource.Config["values"]; !reflect.DeepEqual(got, wantValues) {
+		t.Errorf("values = %v, want %v", got, wantValues)
+	}
+	if got := resource.Metadata["helm/history"]; got != "3:deployed,2:failed" {
+		t.Errorf("history = %q", got)
+	}
+	if resource.Metadata["helm/objects/Deployment"] != "2" || resource.Metadata["helm/objects/Service"] != "1" || resource.Metadata["helm/object-count"] != "3" {
+		t.Errorf("unexpected object counts in %v", resource.Metadata)
+	}
+
+	same := helmReleaseToResource(revision(4, release.StatusDeployed, map[string]any{"image": map[string]any{"tag": "v3"}}), nil, "cluster-a", ReleaseOptions{ValuesDigest: true})
+	if digest := resource.Metadata["helm/values-digest"]; digest == "" || digest != same.Metadata["helm/values-digest"] {
+		t.Errorf("expected equal values to have equal digests, got %q and %q", digest, same.Metadata["helm/values-digest"])
+	}
+}
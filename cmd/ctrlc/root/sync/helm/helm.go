@@ -3,16 +3,22 @@ package helm
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
-	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
+	"github.com/ctrlplanedev/cli/internal/kubefilter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
 )
 
@@ -26,9 +32,10 @@ type clusterConfig struct {
 // The clusterName is used to:
 // - Create unique identifiers across clusters (cluster/namespace/release)
 // - Tag resources with their source cluster for filtering and relationships
-func helmReleaseToResource(release *release.Release, clusterName string) api.ResourceProviderResource {
+func helmReleaseToResource(release *release.Release, history []*release.Release, clusterName string, opts ReleaseOptions) api.ResourceProviderResource {
 	metadata := buildHelmMetadata(release, clusterName)
 	config := buildHelmConfig(release)
+	addReleaseDetails(release, history, opts, config, metadata)
 	identifier := fmt.Sprintf("%s/%s/%s", clusterName, release.Namespace, release.Name)
 
 	return api.ResourceProviderResource{
@@ -87,11 +94,22 @@ func buildHelmConfig(release *release.Release) map[string]any {
 	}
 }
 
+// Options selects the cluster and releases a Helm sync reads and the
+// provider it writes to. Filter.FieldSelector is not supported.
+type Options struct {
+	Provider          string
+	ClusterIdentifier string
+	ClusterName       string
+	Filter            kubefilter.Filter
+	Contexts          kubeconfig.ContextFlags
+	Release           ReleaseOptions
+	// Metadata is set on every synced release, over what it inherits from
+	// the cluster resource, e.g. the vcluster/* keys of a virtual cluster.
+	Metadata map[string]string
+}
+
 func NewSyncHelmCmd() *cobra.Command {
-	var providerName string
-	var clusterIdentifier string
-	var clusterName string
-	var namespace string
+	var opts Options
 
 	cmd := &cobra.Command{
 		Use:   "helm",
@@ -99,51 +117,121 @@ func NewSyncHelmCmd() *cobra.Command {
 		Example: heredoc.Doc(`
 			$ ctrlc sync helm --cluster-identifier 1234567890 --cluster-name my-cluster
 			$ ctrlc sync helm --namespace my-namespace
+			$ ctrlc sync helm --exclude-namespace kube-system --label-selector team=payments
+			$ ctrlc sync helm --context prod-us --context prod-eu --cluster-identifier "{context}"
+
+			# Select releases by their configuration, not just the chart version
+			$ ctrlc sync helm --values-digest --values-path image.tag --values-path replicaCount \
+			    --history 5 --manifest-objects
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			return Sync(cmd.Context(), opts)
+		},
+	}
 
-			// Step 1: Initialize Ctrlplane API client
-			ctrlplaneClient, workspaceId, err := initializeCtrlplaneClient()
-			if err != nil {
-				return err
-			}
+	cmd.Flags().StringVarP(&opts.Provider, "provider", "p", "", "Name of the resource provider ({context} is replaced with the kubeconfig context)")
+	cmd.Flags().StringVarP(&opts.ClusterIdentifier, "cluster-identifier", "c", "", "The identifier of the parent cluster in ctrlplane (if not provided, will use the CLUSTER_IDENTIFIER environment variable; {context} is replaced with the kubeconfig context)")
+	cmd.Flags().StringVarP(&opts.ClusterName, "cluster-name", "n", "", "The name of the cluster")
+	opts.Filter.AddFlags(cmd.Flags())
+	opts.Contexts.AddFlags(cmd.Flags())
+	opts.Release.AddFlags(cmd.Flags())
 
-			// Step 2: Resolve cluster information (name, identifier, kubeconfig)
-			cluster, kubeConfig, err := resolveClusterConfig(ctx, ctrlplaneClient, workspaceId, clusterIdentifier, clusterName)
-			if err != nil {
-				return err
-			}
+	return cmd
+}
 
-			log.Info("Syncing Helm releases", "cluster", cluster.name, "namespace", namespaceOrAll(namespace))
+// Sync lists the Helm releases on the cluster and upserts them as one
+// provider set. With --context or --all-contexts every selected cluster is
+// synced concurrently to its own provider. It backs `ctrlc sync helm` and the
+// Kubernetes controller.
+func Sync(ctx context.Context, opts Options) error {
+	if !opts.Contexts.Multi() {
+		kubeConfig, kubeconfigClusterName, err := kubeconfig.Load()
+		if err != nil {
+			return fmt.Errorf("failed to get kubeconfig: %w", err)
+		}
+		// Resolve cluster identifier (flag takes precedence over environment)
+		clusterIdentifier := opts.ClusterIdentifier
+		if clusterIdentifier == "" {
+			clusterIdentifier = viper.GetString("cluster-identifier")
+		}
+		return syncCluster(ctx, opts, kubeconfigGetter(kubeconfig.Context{Config: kubeConfig}), kubeconfigClusterName, clusterIdentifier)
+	}
 
-			// Step 3: Fetch Helm releases from the Kubernetes cluster
-			releases, err := fetchHelmReleases(kubeConfig, namespace)
-			if err != nil {
-				return err
+	contexts, err := opts.Contexts.LoadContexts()
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 1 {
+		for flag, value := range map[string]string{"provider": opts.Provider, "cluster-identifier": opts.ClusterIdentifier} {
+			if value != "" && !strings.Contains(value, "{context}") {
+				return fmt.Errorf("--%s must contain {context} when syncing several contexts", flag)
 			}
+		}
+	}
+	return kubeconfig.ForEach(ctx, contexts, opts.Contexts.Concurrency, func(ctx context.Context, c kubeconfig.Context) error {
+		clusterOpts := opts
+		clusterOpts.Provider = kubeconfig.Expand(opts.Provider, c.Name)
+		clusterOpts.ClusterName = kubeconfig.Expand(opts.ClusterName, c.Name)
+		return syncCluster(ctx, clusterOpts, kubeconfigGetter(c), c.Name, kubeconfig.Expand(opts.ClusterIdentifier, c.Name))
+	})
+}
+
+// SyncConfig syncs the Helm releases of a cluster that is reached through
+// config rather than the kubeconfig, such as one found by a cloud cluster
+// sync. opts.Contexts is ignored.
+func SyncConfig(ctx context.Context, opts Options, config *rest.Config) error {
+	return syncCluster(ctx, opts, func(namespace string) genericclioptions.RESTClientGetter {
+		return newRESTConfigGetter(config, namespace)
+	}, opts.ClusterName, opts.ClusterIdentifier)
+}
 
-			log.Info("Found Helm releases", "count", len(releases))
+// kubeconfigGetter hands Helm the kubeconfig context, so that it reads the
+// same credentials as the rest of the sync.
+func kubeconfigGetter(kubeContext kubeconfig.Context) func(namespace string) genericclioptions.RESTClientGetter {
+	return func(namespace string) genericclioptions.RESTClientGetter {
+		return getConfigFlags(kubeContext, namespace)
+	}
+}
 
-			// Step 4: Convert Helm releases to Ctrlplane resources
-			resources := convertReleasesToResources(releases, cluster.name)
+// syncCluster syncs the Helm releases of one cluster, reading them through
+// the client configuration that getter returns for a namespace.
+func syncCluster(ctx context.Context, opts Options, getter func(namespace string) genericclioptions.RESTClientGetter, kubeconfigClusterName, clusterIdentifier string) error {
+	// Step 1: Initialize Ctrlplane API client
+	ctrlplaneClient, workspaceId, err := initializeCtrlplaneClient()
+	if err != nil {
+		return err
+	}
 
-			// Step 5: Optionally inherit metadata from parent cluster resource
-			if cluster.identifier != "" {
-				inheritClusterMetadata(ctx, ctrlplaneClient, workspaceId, cluster.identifier, resources)
-			}
+	// Step 2: Resolve cluster information (name, identifier)
+	cluster := resolveClusterConfig(ctx, ctrlplaneClient, workspaceId, clusterIdentifier, opts.ClusterName, kubeconfigClusterName)
 
-			// Step 6: Upsert resources to Ctrlplane
-			return upsertResourcesToCtrlplane(ctx, ctrlplaneClient, workspaceId, resources, cluster.name, providerName)
-		},
+	log.Info("Syncing Helm releases", "cluster", cluster.name, "namespace", namespacesOrAll(opts.Filter.Namespaces))
+
+	// Step 3: Fetch Helm releases from the Kubernetes cluster
+	var releases []*release.Release
+	histories := map[*release.Release][]*release.Release{}
+	for _, namespace := range opts.Filter.Scopes() {
+		found, history, err := fetchHelmReleases(getter(namespace), namespace, opts)
+		if err != nil {
+			return err
+		}
+		releases = append(releases, found...)
+		maps.Copy(histories, history)
 	}
 
-	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Name of the resource provider")
-	cmd.Flags().StringVarP(&clusterIdentifier, "cluster-identifier", "c", "", "The identifier of the parent cluster in ctrlplane (if not provided, will use the CLUSTER_IDENTIFIER environment variable)")
-	cmd.Flags().StringVarP(&clusterName, "cluster-name", "n", "", "The name of the cluster")
-	cmd.Flags().StringVar(&namespace, "namespace", "", "Kubernetes namespace to sync Helm releases from (if not provided, syncs from all namespaces)")
+	log.Info("Found Helm releases", "count", len(releases))
 
-	return cmd
+	// Step 4: Convert Helm releases to Ctrlplane resources
+	resources := convertReleasesToResources(releases, histories, cluster.name, opts.Release)
+
+	// Step 5: Optionally inherit metadata from parent cluster resource
+	if cluster.identifier != "" {
+		inheritClusterMetadata(ctx, ctrlplaneClient, workspaceId, cluster.identifier, resources)
+	}
+	setMetadata(resources, opts.Metadata)
+
+	// Step 6: Upsert resources to Ctrlplane
+	return upsertResourcesToCtrlplane(ctx, resources, cluster.name, opts.Provider)
 }
 
 // --- Helper Functions ---
@@ -163,26 +251,13 @@ func initializeCtrlplaneClient() (*api.ClientWithResponses, string, error) {
 	return ctrlplaneClient, workspaceId, nil
 }
 
-// resolveClusterConfig determines the cluster name and identifier from multiple sources:
-// 1. Explicit --cluster-identifier flag (takes precedence)
-// 2. Environment variable CLUSTER_IDENTIFIER
-// 3. Kubeconfig current-context (fallback)
+// resolveClusterConfig determines the cluster name and identifier. The
+// identifier comes from --cluster-identifier or the CLUSTER_IDENTIFIER
+// environment variable.
 //
 // If a cluster identifier is provided, we try to fetch the cluster resource from Ctrlplane
 // to get the canonical cluster name. Otherwise, we use the name from kubeconfig.
-func resolveClusterConfig(ctx context.Context, client *api.ClientWithResponses, workspaceId, flagIdentifier, flagName string) (clusterConfig, *rest.Config, error) {
-	// Get kubeconfig first since we need it regardless
-	kubeConfig, kubeconfigClusterName, err := getKubeConfig()
-	if err != nil {
-		return clusterConfig{}, nil, fmt.Errorf("failed to get kubeconfig: %w", err)
-	}
-
-	// Resolve cluster identifier (flag takes precedence over environment)
-	clusterIdentifier := flagIdentifier
-	if clusterIdentifier == "" {
-		clusterIdentifier = viper.GetString("cluster-identifier")
-	}
-
+func resolveClusterConfig(ctx context.Context, client *api.ClientWithResponses, workspaceId, clusterIdentifier, flagName, kubeconfigClusterName string) clusterConfig {
 	// Determine final cluster name with priority:
 	// 1. Name from Ctrlplane API (if identifier provided and resource exists)
 	// 2. Explicit --cluster-name flag
@@ -206,35 +281,56 @@ func resolveClusterConfig(ctx context.Context, client *api.ClientWithResponses,
 	return clusterConfig{
 		name:       resolvedClusterName,
 		identifier: clusterIdentifier,
-	}, kubeConfig, nil
+	}
 }
 
-// fetchHelmReleases queries the Kubernetes cluster for all Helm releases
-func fetchHelmReleases(kubeConfig *rest.Config, namespace string) ([]*release.Release, error) {
+// fetchHelmReleases queries the Kubernetes cluster for the Helm releases in a
+// namespace, or all namespaces, that pass the filter. With --history it also
+// returns the latest revisions of every release, newest first.
+func fetchHelmReleases(getter genericclioptions.RESTClientGetter, namespace string, opts Options) ([]*release.Release, map[*release.Release][]*release.Release, error) {
 	// Initialize Helm action configuration
 	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(getConfigFlags(kubeConfig, namespace), namespace, "secret", log.Debugf); err != nil {
-		return nil, fmt.Errorf("failed to initialize Helm action config: %w", err)
+	if err := actionConfig.Init(getter, namespace, "secret", log.Debugf); err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize Helm action config: %w", err)
 	}
 
 	// Configure list operation
 	listClient := action.NewList(actionConfig)
-	listClient.All = true                        // Include all releases (not just deployed)
-	listClient.AllNamespaces = (namespace == "") // Scan all namespaces if none specified
+	listClient.All = true                           // Include all releases (not just deployed)
+	listClient.AllNamespaces = (namespace == "")    // Scan all namespaces if none specified
+	listClient.Selector = opts.Filter.LabelSelector // Match release labels (helm install --labels)
 
 	releases, err := listClient.Run()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list Helm releases: %w", err)
+		return nil, nil, fmt.Errorf("failed to list Helm releases: %w", err)
+	}
+	releases = filterReleases(releases, opts.Filter)
+
+	histories := map[*release.Release][]*release.Release{}
+	if opts.Release.History > 0 {
+		for _, rel := range releases {
+			revisions, err := actionConfig.Releases.History(rel.Name)
+			if err != nil {
+				log.Warn("Failed to get Helm release history", "release", rel.Name, "namespace", rel.Namespace, "error", err)
+				continue
+			}
+			// Without a namespace the storage spans all namespaces
+			revisions = slices.DeleteFunc(revisions, func(revision *release.Release) bool {
+				return revision.Namespace != rel.Namespace
+			})
+			releaseutil.Reverse(revisions, releaseutil.SortByRevision)
+			histories[rel] = revisions
+		}
 	}
 
-	return releases, nil
+	return releases, histories, nil
 }
 
 // convertReleasesToResources transforms Helm releases into Ctrlplane resource format
-func convertReleasesToResources(releases []*release.Release, clusterName string) []api.ResourceProviderResource {
+func convertReleasesToResources(releases []*release.Release, histories map[*release.Release][]*release.Release, clusterName string, opts ReleaseOptions) []api.ResourceProviderResource {
 	resources := make([]api.ResourceProviderResource, 0, len(releases))
 	for _, release := range releases {
-		resource := helmReleaseToResource(release, clusterName)
+		resource := helmReleaseToResource(release, histories[release], clusterName, opts)
 		resources = append(resources, resource)
 	}
 	return resources
@@ -268,35 +364,53 @@ func inheritClusterMetadata(ctx context.Context, client *api.ClientWithResponses
 	log.Debug("Inherited metadata from cluster resource", "keys", len(clusterResource.JSON200.Metadata))
 }
 
-// upsertResourcesToCtrlplane sends the resources to Ctrlplane via the resource provider API
-func upsertResourcesToCtrlplane(ctx context.Context, client *api.ClientWithResponses, workspaceId string, resources []api.ResourceProviderResource, clusterName, providerName string) error {
+// setMetadata sets the given metadata on every release.
+func setMetadata(resources []api.ResourceProviderResource, metadata map[string]string) {
+	for _, resource := range resources {
+		maps.Copy(resource.Metadata, metadata)
+	}
+}
+
+// upsertResourcesToCtrlplane sends the resources to Ctrlplane via the shared sync path
+func upsertResourcesToCtrlplane(ctx context.Context, resources []api.ResourceProviderResource, clusterName, providerName string) error {
 	// Generate default provider name if not specified
 	if providerName == "" {
 		providerName = fmt.Sprintf("helm-cluster-%s", clusterName)
 	}
 
 	log.Info("Upserting to Ctrlplane", "provider", providerName, "resources", len(resources))
+	return ctrlp.UpsertResources(ctx, resources, &providerName, ctrlp.WithRelationshipRules(relationshipRules...))
+}
 
-	// Create or get resource provider
-	resourceProvider, err := resourceprovider.New(client, workspaceId, providerName)
-	if err != nil {
-		return fmt.Errorf("failed to create resource provider: %w", err)
-	}
+// relationshipRules links Helm releases to the cluster resource they are
+// installed in. kubernetes/name is set from the cluster resource's name.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "cluster",
+		Name:      "Helm Release Cluster",
+		Cel: `from.version == "ctrlplane.dev/helm/release/v1" &&
+			to.version == "ctrlplane.dev/kubernetes/cluster/v1" &&
+			from.metadata["kubernetes/name"] == to.name`,
+	},
+}
 
-	// Upsert all resources in one batch
-	resp, err := resourceProvider.UpsertResource(ctx, resources)
-	if err != nil {
-		return fmt.Errorf("failed to upsert resources: %w", err)
+// filterReleases drops releases in excluded namespaces and releases labelled
+// ctrlplane.dev/sync=false
+func filterReleases(releases []*release.Release, filter kubefilter.Filter) []*release.Release {
+	kept := make([]*release.Release, 0, len(releases))
+	for _, release := range releases {
+		if !filter.IncludesNamespace(release.Namespace) || kubefilter.OptedOut(release.Labels) {
+			continue
+		}
+		kept = append(kept, release)
 	}
-
-	log.Info("Successfully synced resources", "status", resp.Status)
-	return nil
+	return kept
 }
 
-// namespaceOrAll returns a human-readable string for logging
-func namespaceOrAll(namespace string) string {
-	if namespace == "" {
+// namespacesOrAll returns a human-readable string for logging
+func namespacesOrAll(namespaces []string) string {
+	if len(namespaces) == 0 {
 		return "all namespaces"
 	}
-	return namespace
+	return strings.Join(namespaces, ",")
 }
@@ -0,0 +1,21 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+func TestSetMetadata_OverridesInherited(t *testing.T) {
+	resources := []api.ResourceProviderResource{
+		{Name: "web", Metadata: map[string]string{"vcluster/name": "host", "helm/release": "web"}},
+	}
+	setMetadata(resources, map[string]string{"vcluster/name": "tenant", "vcluster/namespace": "tenants"})
+
+	want := map[string]string{"vcluster/name": "tenant", "vcluster/namespace": "tenants", "helm/release": "web"}
+	for key, value := range want {
+		if got := resources[0].Metadata[key]; got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package httpsource
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ctrlplanedev/cli/internal/jsonmap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	paginationOffset = "offset"
+	paginationCursor = "cursor"
+	paginationLink   = "link"
+
+	defaultMaxPages = 1000
+	defaultTimeout  = 30 * time.Second
+)
+
+// Config is the document accepted by `ctrlc sync http --config`.
+type Config struct {
+	// Provider is the resource provider name; --provider overrides it.
+	Provider string            `yaml:"provider"`
+	URL      string            `yaml:"url"`
+	Headers  map[string]string `yaml:"headers"`
+	Auth     *Auth             `yaml:"auth"`
+	// Timeout bounds each HTTP request, e.g. "30s".
+	Timeout string `yaml:"timeout"`
+
+	Pagination *Pagination `yaml:"pagination"`
+
+	// Items is a JSONPath selecting the items of each response page, e.g.
+	// "{.data[*]}". When empty the response must be a JSON array.
+	Items   string          `yaml:"items"`
+	Mapping jsonmap.Mapping `yaml:"mapping"`
+
+	timeout time.Duration
+}
+
+// Auth adds a header whose value is read from an environment variable, so
+// secrets never live in the config file.
+type Auth struct {
+	// Header defaults to Authorization.
+	Header string `yaml:"header"`
+	Env    string `yaml:"env"`
+	// Prefix is prepended to the value, e.g. "Bearer ".
+	Prefix string `yaml:"prefix"`
+}
+
+// Pagination describes how to request the next page.
+type Pagination struct {
+	// Type is offset, cursor or link.
+	Type string `yaml:"type"`
+
+	// offset: OffsetParam and LimitParam are set on the query. Paging stops
+	// when a page returns fewer than Limit items.
+	OffsetParam string `yaml:"offsetParam"`
+	LimitParam  string `yaml:"limitParam"`
+	Limit       int    `yaml:"limit"`
+
+	// cursor: CursorPath is a JSONPath to the next cursor in the response,
+	// sent back as CursorParam. Paging stops when the cursor is empty.
+	CursorParam string `yaml:"cursorParam"`
+	CursorPath  string `yaml:"cursorPath"`
+
+	// link: follows the rel="next" URL of the Link response header.
+
+	// MaxPages guards against endless pagination.
+	MaxPages int `yaml:"maxPages"`
+}
+
+// LoadConfig reads and validates an HTTP source configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses an HTTP source configuration, applies defaults and
+// validates it.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse source config: %w", err)
+	}
+
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("source config is missing required field 'url'")
+	}
+	if err := cfg.Mapping.Validate("mapping."); err != nil {
+		return nil, fmt.Errorf("invalid source config: %w", err)
+	}
+
+	cfg.timeout = defaultTimeout
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", cfg.Timeout, err)
+		}
+		cfg.timeout = timeout
+	}
+
+	if cfg.Auth != nil {
+		if cfg.Auth.Env == "" {
+			return nil, fmt.Errorf("auth.env is required when auth is set")
+		}
+		if cfg.Auth.Header == "" {
+			cfg.Auth.Header = "Authorization"
+		}
+	}
+
+	if p := cfg.Pagination; p != nil {
+		if p.MaxPages <= 0 {
+			p.MaxPages = defaultMaxPages
+		}
+		switch p.Type {
+		case paginationOffset:
+			if p.OffsetParam == "" {
+				p.OffsetParam = "offset"
+			}
+			if p.LimitParam == "" {
+				p.LimitParam = "limit"
+			}
+			if p.Limit <= 0 {
+				p.Limit = 100
+			}
+		case paginationCursor:
+			if p.CursorParam == "" || p.CursorPath == "" {
+				return nil, fmt.Errorf("cursor pagination requires 'cursorParam' and 'cursorPath'")
+			}
+		case paginationLink:
+		default:
+			return nil, fmt.Errorf("unsupported pagination type %q (expected offset, cursor or link)", p.Type)
+		}
+	}
+
+	return &cfg, nil
+}
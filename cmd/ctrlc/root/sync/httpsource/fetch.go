@@ -0,0 +1,187 @@
+package httpsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/jsonmap"
+)
+
+// linkNext extracts the rel="next" URL from a Link header.
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;[^,]*rel="?next"?`)
+
+// FetchResources requests every page described by cfg and maps the items to
+// resources.
+func FetchResources(ctx context.Context, client *http.Client, cfg *Config) ([]api.ResourceProviderResource, error) {
+	items, err := fetchItems(ctx, client, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]api.ResourceProviderResource, 0, len(items))
+	for i, item := range items {
+		resource, err := cfg.Mapping.Apply(item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+func fetchItems(ctx context.Context, client *http.Client, cfg *Config) ([]any, error) {
+	next, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	p := cfg.Pagination
+	if p != nil && p.Type == paginationOffset {
+		next = withQuery(next, p.OffsetParam, "0")
+		next = withQuery(next, p.LimitParam, strconv.Itoa(p.Limit))
+	}
+
+	var items []any
+	offset := 0
+	for page := 1; next != nil; page++ {
+		if p != nil && page > p.MaxPages {
+			return nil, fmt.Errorf("stopped after %d pages, raise pagination.maxPages if this is expected", p.MaxPages)
+		}
+
+		body, header, err := get(ctx, client, cfg, next.String())
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+
+		pageItems, err := selectItems(cfg.Items, body)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		items = append(items, pageItems...)
+		log.Debug("Fetched page", "page", page, "items", len(pageItems), "url", next.String())
+
+		if p == nil {
+			break
+		}
+		switch p.Type {
+		case paginationOffset:
+			if len(pageItems) < p.Limit {
+				next = nil
+				break
+			}
+			offset += len(pageItems)
+			next = withQuery(next, p.OffsetParam, strconv.Itoa(offset))
+		case paginationCursor:
+			cursor, err := jsonmap.Evaluate(p.CursorPath, body)
+			if err != nil {
+				return nil, fmt.Errorf("page %d: failed to read cursor: %w", page, err)
+			}
+			if cursor == "" {
+				next = nil
+				break
+			}
+			next = withQuery(next, p.CursorParam, cursor)
+		case paginationLink:
+			match := linkNext.FindStringSubmatch(header.Get("Link"))
+			if match == nil {
+				next = nil
+				break
+			}
+			link, err := next.Parse(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("page %d: invalid next link: %w", page, err)
+			}
+			next = link
+		}
+	}
+	return items, nil
+}
+
+func get(ctx context.Context, client *http.Client, cfg *Config, target string) (any, http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range cfg.Headers {
+		req.Header.Set(key, os.ExpandEnv(value))
+	}
+	if cfg.Auth != nil {
+		secret, ok := os.LookupEnv(cfg.Auth.Env)
+		if !ok {
+			return nil, nil, fmt.Errorf("environment variable %s for auth is not set", cfg.Auth.Env)
+		}
+		req.Header.Set(cfg.Auth.Header, cfg.Auth.Prefix+secret)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, target, truncate(string(data), 200))
+	}
+
+	var body any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, nil, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	return body, resp.Header, nil
+}
+
+func withQuery(u *url.URL, key, value string) *url.URL {
+	next := *u
+	query := next.Query()
+	query.Set(key, value)
+	next.RawQuery = query.Encode()
+	return &next
+}
+
+// selectItems applies the items JSONPath to a response page. Without a path
+// the page itself must be an array.
+func selectItems(path string, body any) ([]any, error) {
+	if path == "" {
+		items, ok := body.([]any)
+		if !ok {
+			return nil, fmt.Errorf("response is not a JSON array, set 'items' to select the items")
+		}
+		return items, nil
+	}
+
+	values, err := jsonmap.Find(path, body)
+	if err != nil {
+		return nil, err
+	}
+	// A path to the array itself, e.g. "{.data}", selects its elements.
+	if len(values) == 1 {
+		if items, ok := values[0].([]any); ok {
+			return items, nil
+		}
+	}
+	return values, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,82 @@
+package httpsource
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/charmbracelet/log"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/spf13/cobra"
+)
+
+func NewSyncHTTPCmd() *cobra.Command {
+	var configPath string
+	var providerName string
+
+	cmd := &cobra.Command{
+		Use:   "http",
+		Short: "Sync resources from a JSON HTTP API described by a config file",
+		Long: heredoc.Doc(`
+			Fetches items from a JSON API, following pagination, and maps each
+			item to a resource with JSONPath templates. This replaces
+			"curl | jq | ctrlc sync pipe" pipelines.
+		`),
+		Example: heredoc.Doc(`
+			$ ctrlc sync http --config source.yaml --interval 10m
+
+			# source.yaml
+			provider: cmdb
+			url: https://cmdb.internal/api/servers
+			auth:
+			  env: CMDB_TOKEN
+			  prefix: "Bearer "
+			pagination:
+			  type: cursor
+			  cursorParam: cursor
+			  cursorPath: "{.next}"
+			items: "{.data}"
+			mapping:
+			  name: "{.hostname}"
+			  identifier: "cmdb/{.id}"
+			  kind: Server
+			  version: cmdb/v1
+			  config:
+			    ip: "{.network.ip}"
+			    tags: "{.tags}"
+			  metadata:
+			    rack: "{.location.rack}"
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			// The config is reloaded on every --interval run, so its provider
+			// is resolved each time instead of being stored in the flag.
+			provider := providerName
+			if provider == "" {
+				provider = cfg.Provider
+			}
+			if provider == "" {
+				return fmt.Errorf("set the provider name with --provider or 'provider' in the config")
+			}
+
+			ctx := cmd.Context()
+			log.Info("Fetching resources", "url", cfg.URL)
+			resources, err := FetchResources(ctx, http.DefaultClient, cfg)
+			if err != nil {
+				return err
+			}
+
+			log.Info("Syncing resources from HTTP source", "provider", provider, "count", len(resources))
+			return ctrlp.UpsertResources(ctx, resources, &provider)
+		},
+	}
+
+	cmd.Flags().StringVar(&configPath, "config", "", "Path to the HTTP source config file")
+	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Name of the resource provider (overrides the config)")
+	cmd.MarkFlagRequired("config")
+
+	return cmd
+}
@@ -0,0 +1,127 @@
+package httpsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+var servers = []map[string]any{
+	{"id": 1, "hostname": "web-1", "ip": "10.0.0.1", "tags": []any{"a"}},
+	{"id": 2, "hostname": "web-2", "ip": "10.0.0.2"},
+	{"id": 3, "hostname": "db-1", "ip": "10.0.0.3", "rack": "r1"},
+}
+
+const mapping = `
+mapping:
+  name: "{.hostname}"
+  identifier: "cmdb/{.id}"
+  kind: Server
+  version: cmdb/v1
+  config:
+    ip: "{.ip}"
+    tags: "{.tags}"
+  metadata:
+    rack: "{.rack}"
+`
+
+func parse(t *testing.T, doc string) *Config {
+	t.Helper()
+	cfg, err := ParseConfig([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestFetchResources_CursorPaginationAndAuth(t *testing.T) {
+	t.Setenv("CMDB_TOKEN", "secret")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		end := min(start+2, len(servers))
+		next := ""
+		if end < len(servers) {
+			next = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": servers[start:end], "next": next})
+	}))
+	defer server.Close()
+
+	cfg := parse(t, fmt.Sprintf(`
+url: %s/servers
+auth:
+  env: CMDB_TOKEN
+  prefix: "Bearer "
+pagination:
+  type: cursor
+  cursorParam: cursor
+  cursorPath: "{.next}"
+items: "{.data}"
+`, server.URL)+mapping)
+
+	resources, err := FetchResources(context.Background(), server.Client(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(resources))
+	}
+
+	first := resources[0]
+	if first.Name != "web-1" || first.Identifier != "cmdb/1" || first.Kind != "Server" || first.Version != "cmdb/v1" {
+		t.Fatalf("unexpected resource: %+v", first)
+	}
+	if first.Config["ip"] != "10.0.0.1" {
+		t.Fatalf("expected ip config, got %#v", first.Config)
+	}
+	if tags, ok := first.Config["tags"].([]any); !ok || len(tags) != 1 {
+		t.Fatalf("expected tags to keep their JSON type, got %#v", first.Config["tags"])
+	}
+	if _, ok := first.Metadata["rack"]; ok {
+		t.Fatalf("expected missing rack to be skipped, got %#v", first.Metadata)
+	}
+	if resources[2].Metadata["rack"] != "r1" {
+		t.Fatalf("expected rack metadata, got %#v", resources[2].Metadata)
+	}
+}
+
+func TestFetchResources_OffsetAndLinkPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/offset":
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			end := min(offset+limit, len(servers))
+			json.NewEncoder(w).Encode(servers[offset:end])
+		case "/link":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page+1 < len(servers) {
+				w.Header().Set("Link", fmt.Sprintf(`</link?page=%d>; rel="next"`, page+1))
+			}
+			json.NewEncoder(w).Encode(servers[page : page+1])
+		}
+	}))
+	defer server.Close()
+
+	for _, pagination := range []string{
+		fmt.Sprintf("url: %s/offset\npagination:\n  type: offset\n  limit: 2\n", server.URL),
+		fmt.Sprintf("url: %s/link\npagination:\n  type: link\n", server.URL),
+	} {
+		resources, err := FetchResources(context.Background(), server.Client(), parse(t, pagination+mapping))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resources) != 3 || resources[2].Identifier != "cmdb/3" {
+			t.Fatalf("unexpected resources for %q: %+v", pagination, resources)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/helm"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+type controllerOptions struct {
+	options
+	watch    bool
+	interval time.Duration
+
+	helm     bool
+	helmOpts helm.Options
+
+	leaderElect    bool
+	leaseName      string
+	leaseNamespace string
+	healthAddress  string
+}
+
+func NewControllerCmd() *cobra.Command {
+	var opts controllerOptions
+
+	cmd := &cobra.Command{
+		Use:   "controller",
+		Short: "Run the Kubernetes and Helm syncs as a long-running in-cluster controller",
+		Long: heredoc.Doc(`
+			Runs inside the cluster with its service account and keeps syncing
+			it until stopped. Replicas elect a leader through a Lease, and only
+			the leader syncs, so several replicas can run safely. /healthz and
+			/readyz are served for liveness and readiness probes.
+
+			Use "ctrlc sync kubernetes manifests" to generate the RBAC and
+			Deployment for it.
+		`),
+		Example: heredoc.Doc(`
+			$ ctrlc sync kubernetes controller --provider my-cluster-k8s --cluster-identifier my-cluster --watch --helm
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.interval <= 0 {
+				return fmt.Errorf("--interval must be positive, got %s", opts.interval)
+			}
+			if opts.leaderElect && opts.leaseNamespace == "" {
+				opts.leaseNamespace = kubeconfig.Namespace()
+				if opts.leaseNamespace == "" {
+					return fmt.Errorf("--lease-namespace is required outside a pod")
+				}
+			}
+			opts.helmOpts.ClusterIdentifier = opts.clusterIdentifier
+			opts.helmOpts.ClusterName = opts.clusterName
+			opts.helmOpts.Filter = opts.filter
+			opts.helmOpts.Filter.FieldSelector = ""
+			opts.helmOpts.Contexts = opts.contexts
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return opts.run(ctx)
+		},
+	}
+
+	opts.addFlags(cmd.Flags())
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "Push Kubernetes changes as they happen instead of listing on the interval")
+	cmd.Flags().DurationVarP(&opts.interval, "interval", "i", 5*time.Minute, "How often to sync Helm releases, and Kubernetes resources without --watch")
+	cmd.Flags().BoolVar(&opts.helm, "helm", false, "Also sync Helm releases from this process")
+	cmd.Flags().StringVar(&opts.helmOpts.Provider, "helm-provider", "", "Name of the Helm resource provider (default is helm-cluster-<cluster name>)")
+	cmd.Flags().BoolVar(&opts.leaderElect, "leader-elect", true, "Elect a leader with a Lease so only one replica syncs")
+	cmd.Flags().StringVar(&opts.leaseName, "lease-name", "ctrlc-sync", "Name of the leader election Lease")
+	cmd.Flags().StringVar(&opts.leaseNamespace, "lease-namespace", "", "Namespace of the leader election Lease (default is the pod's namespace)")
+	cmd.Flags().StringVar(&opts.healthAddress, "health-address", ":8081", "Listen address for /healthz and /readyz; '-' disables them")
+	// Unlike the Helm sync, the Kubernetes sync has no default provider name.
+	cmd.MarkFlagRequired("provider")
+
+	return cmd
+}
+
+func (o *controllerOptions) run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var ready atomic.Bool
+	var watchdog *leaderelection.HealthzAdaptor
+	if o.leaderElect {
+		// A leader that cannot renew its Lease for this long fails /healthz
+		// so the kubelet restarts it.
+		watchdog = leaderelection.NewLeaderHealthzAdaptor(20 * time.Second)
+	}
+
+	serverErr := make(chan error, 1)
+	if o.healthAddress != "-" {
+		go func() {
+			serverErr <- serveHealth(ctx, o.healthAddress, watchdog, &ready)
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- o.elect(ctx, watchdog, &ready)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		return o.waitHealth(serverErr)
+	case err := <-serverErr:
+		// Without the health server the probes fail, so stop syncing and
+		// exit rather than run unobserved.
+		cancel()
+		syncErr := <-done
+		if err != nil {
+			return fmt.Errorf("health server failed: %w", err)
+		}
+		return syncErr
+	}
+}
+
+// elect runs the syncs until ctx is cancelled, holding the Lease while doing
+// so when leader election is enabled.
+func (o *controllerOptions) elect(ctx context.Context, watchdog *leaderelection.HealthzAdaptor, ready *atomic.Bool) error {
+	if !o.leaderElect {
+		ready.Store(true)
+		o.lead(ctx)
+		return nil
+	}
+
+	config, _, err := kubeconfig.Load()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to determine leader election identity: %w", err)
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: o.leaseName, Namespace: o.leaseNamespace},
+		Client:     clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		ReleaseOnCancel: true,
+		WatchDog:        watchdog,
+		Name:            o.leaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: o.lead,
+			OnStoppedLeading: func() {
+				log.Info("Stopped leading", "identity", identity)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Info("Following leader", "leader", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Standby replicas are ready too; they take over as soon as the Lease
+	// expires.
+	ready.Store(true)
+	log.Info("Waiting for leadership", "lease", o.leaseNamespace+"/"+o.leaseName, "identity", identity)
+	// Run returns when leadership is lost or ctx is cancelled. It does not
+	// wait for OnStartedLeading to return, so a former leader exits rather
+	// than rejoin the election while its syncs may still be running; the
+	// kubelet restarts it as a standby.
+	elector.Run(ctx)
+	ready.Store(false)
+	if ctx.Err() == nil {
+		return fmt.Errorf("lost leadership of lease %s/%s", o.leaseNamespace, o.leaseName)
+	}
+	return nil
+}
+
+// waitHealth waits for the health server to shut down and returns its error.
+func (o *controllerOptions) waitHealth(serverErr <-chan error) error {
+	if o.healthAddress == "-" {
+		return nil
+	}
+	return <-serverErr
+}
+
+// lead runs the selected syncs until ctx is cancelled, which happens on
+// shutdown or when leadership is lost.
+func (o *controllerOptions) lead(ctx context.Context) {
+	log.Info("Started leading, running syncs")
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// In watch mode run only returns on failure, and is restarted on
+		// the next tick.
+		every(ctx, "kubernetes", o.interval, func(ctx context.Context) error {
+			return o.options.run(ctx, o.watch)
+		})
+	}()
+
+	if o.helm {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			every(ctx, "helm", o.interval, func(ctx context.Context) error {
+				return helm.Sync(ctx, o.helmOpts)
+			})
+		}()
+	}
+
+	wg.Wait()
+}
+
+// every calls run immediately and then on every tick of interval until ctx
+// is cancelled. Failures are logged and retried on the next tick.
+func every(ctx context.Context, name string, interval time.Duration, run func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if err := run(ctx); err != nil && ctx.Err() == nil {
+			log.Error("Sync failed", "sync", name, "error", err)
+		} else if err == nil {
+			log.Info("Sync complete", "sync", name, "duration", time.Since(start))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// serveHealth exposes /healthz and /readyz until ctx is cancelled. /healthz
+// fails when the leader has stopped renewing its Lease; /readyz reports
+// whether the controller has started and is not shutting down.
+func serveHealth(ctx context.Context, address string, watchdog *leaderelection.HealthzAdaptor, ready *atomic.Bool) error {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           healthHandler(ctx, watchdog, ready),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Serving health checks", "address", address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// healthHandler serves /healthz and /readyz.
+func healthHandler(ctx context.Context, watchdog *leaderelection.HealthzAdaptor, ready *atomic.Bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if watchdog != nil {
+			if err := watchdog.Check(r); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() || ctx.Err() != nil {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	return mux
+}
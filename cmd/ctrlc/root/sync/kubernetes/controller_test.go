@@ -0,0 +1,118 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection"
+)
+
+func TestHealthHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		ready      bool
+		cancelled  bool
+		wantHealth int
+		wantReady  int
+	}{
+		{name: "starting", wantHealth: http.StatusOK, wantReady: http.StatusServiceUnavailable},
+		{name: "ready", ready: true, wantHealth: http.StatusOK, wantReady: http.StatusOK},
+		{name: "shutting down", ready: true, cancelled: true, wantHealth: http.StatusOK, wantReady: http.StatusServiceUnavailable},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+			var ready atomic.Bool
+			ready.Store(tt.ready)
+
+			// A watchdog without a leader elector, as before the first
+			// election, reports healthy.
+			server := httptest.NewServer(healthHandler(ctx, leaderelection.NewLeaderHealthzAdaptor(time.Second), &ready))
+			defer server.Close()
+
+			for path, want := range map[string]int{"/healthz": tt.wantHealth, "/readyz": tt.wantReady} {
+				resp, err := http.Get(server.URL + path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != want {
+					t.Errorf("%s = %d, want %d", path, resp.StatusCode, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServeHealth_AddressInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	var ready atomic.Bool
+	done := make(chan error, 1)
+	go func() {
+		done <- serveHealth(context.Background(), listener.Addr().String(), nil, &ready)
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected an error for an address in use")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected serveHealth to fail")
+	}
+}
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		every(ctx, "test", 5*time.Millisecond, func(ctx context.Context) error {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+			// Failures are retried on the next tick.
+			return errors.New("failed")
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected every to return once ctx is cancelled")
+	}
+	if runs != 3 {
+		t.Errorf("ran %d times, want 3", runs)
+	}
+}
+
+func TestControllerCmd_RejectsNonPositiveInterval(t *testing.T) {
+	for _, interval := range []string{"0", "-1m"} {
+		cmd := NewControllerCmd()
+		cmd.SetArgs([]string{"--provider", "test", "--interval", interval})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		err := cmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "--interval must be positive") {
+			t.Errorf("--interval %s: error = %v", interval, err)
+		}
+	}
+}
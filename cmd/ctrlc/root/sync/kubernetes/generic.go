@@ -0,0 +1,273 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/jsonmap"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// ParseGroupVersionResource parses the value of --resource. Core resources
+// may omit the group, e.g. "v1/services" next to "apps/v1/statefulsets".
+func ParseGroupVersionResource(s string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(s, "/")
+	for _, part := range parts {
+		if part == "" {
+			return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q (expected group/version/resource)", s)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+	case 3:
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q (expected group/version/resource)", s)
+	}
+}
+
+// GroupVersionResources collects repeated --resource flags.
+type GroupVersionResources []schema.GroupVersionResource
+
+func (g *GroupVersionResources) String() string {
+	if g == nil {
+		return ""
+	}
+	out := make([]string, len(*g))
+	for i, gvr := range *g {
+		out[i] = gvrString(gvr)
+	}
+	return strings.Join(out, ",")
+}
+
+func (g *GroupVersionResources) Type() string {
+	return "group/version/resource"
+}
+
+func (g *GroupVersionResources) Set(value string) error {
+	gvr, err := ParseGroupVersionResource(value)
+	if err != nil {
+		return err
+	}
+	*g = append(*g, gvr)
+	return nil
+}
+
+func gvrString(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Version + "/" + gvr.Resource
+	}
+	return gvr.Group + "/" + gvr.Version + "/" + gvr.Resource
+}
+
+// Template customizes how objects synced with --resource become resources.
+// Mappings are JSONPath templates, as in `ctrlc sync http`, evaluated against
+// the object with an extra top-level "cluster" field holding the cluster
+// name, so "{.metadata.name}" and "{.cluster.name}" are both valid. Fields
+// left empty keep their defaults.
+type Template struct {
+	jsonmap.Mapping `yaml:",inline"`
+
+	// Resources overrides the mapping for a single resource, keyed like
+	// --resource, e.g. "argoproj.io/v1alpha1/rollouts".
+	Resources map[string]jsonmap.Mapping `yaml:"resources"`
+}
+
+// LoadTemplate reads a mapping template file.
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	var template Template
+	if err := yaml.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	for key := range template.Resources {
+		if _, err := ParseGroupVersionResource(key); err != nil {
+			return nil, fmt.Errorf("template: %w", err)
+		}
+	}
+	return &template, nil
+}
+
+// mappingFor layers the template for gvr over the defaults for its kind.
+func (t *Template) mappingFor(gvr schema.GroupVersionResource, resource metav1.APIResource) jsonmap.Mapping {
+	mapping := defaultMapping(resource)
+	if t == nil {
+		return mapping
+	}
+	for _, override := range []jsonmap.Mapping{t.Mapping, t.Resources[gvrString(gvr)]} {
+		if override.Name != "" {
+			mapping.Name = override.Name
+		}
+		if override.Identifier != "" {
+			mapping.Identifier = override.Identifier
+		}
+		if override.Kind != "" {
+			mapping.Kind = override.Kind
+		}
+		if override.Version != "" {
+			mapping.Version = override.Version
+		}
+		if override.Config != nil {
+			mapping.Config = override.Config
+		}
+		if override.Metadata != nil {
+			mapping.Metadata = maps.Clone(mapping.Metadata)
+			if mapping.Metadata == nil {
+				mapping.Metadata = map[string]string{}
+			}
+			maps.Copy(mapping.Metadata, override.Metadata)
+		}
+	}
+	return mapping
+}
+
+// defaultMapping follows the naming of the built-in node, namespace and
+// deployment resources, e.g. KubernetesStatefulSet with
+// ctrlplane.dev/kubernetes/statefulset/v1.
+func defaultMapping(resource metav1.APIResource) jsonmap.Mapping {
+	mapping := jsonmap.Mapping{
+		Name:       "{.cluster.name}/{.metadata.name}",
+		Identifier: "{.metadata.uid}",
+		Kind:       "Kubernetes" + resource.Kind,
+		Version:    fmt.Sprintf("ctrlplane.dev/kubernetes/%s/v1", strings.ToLower(resource.Kind)),
+		Config: map[string]any{
+			"id":         "{.metadata.uid}",
+			"name":       "{.metadata.name}",
+			"apiVersion": "{.apiVersion}",
+			"kind":       "{.kind}",
+		},
+	}
+	if resource.Namespaced {
+		mapping.Name = "{.cluster.name}/{.metadata.namespace}/{.metadata.name}"
+		mapping.Config.(map[string]any)["namespace"] = "{.metadata.namespace}"
+	}
+	return mapping
+}
+
+// FetchResources lists every object of the given resources with the dynamic
+// client and maps them through the template.
+func (s *syncConfig) FetchResources(ctx context.Context, client dynamic.Interface, disco discovery.DiscoveryInterface, gvrs GroupVersionResources, template *Template) error {
+	for _, gvr := range gvrs {
+		resource, err := discoverResource(disco, gvr)
+		if err != nil {
+			return err
+		}
+
+		// Cluster-scoped objects are listed once, whatever --namespace says.
+		filter := s.filter
+		if !resource.Namespaced {
+			filter.Namespaces = nil
+		}
+		objects, err := filter.List(ctx, client, gvr)
+		if err != nil {
+			return err
+		}
+
+		mapping := template.mappingFor(gvr, resource)
+		for _, object := range objects {
+			processed, err := processObject(s.clusterName, mapping, object)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", gvrString(gvr), objectKey(object), err)
+			}
+			s.resources = append(s.resources, processed)
+		}
+		log.Info("Fetched Kubernetes resources", "resource", gvrString(gvr), "count", len(objects))
+	}
+	return nil
+}
+
+// discoverResource confirms the API server serves gvr and returns its kind
+// and scope.
+func discoverResource(disco discovery.DiscoveryInterface, gvr schema.GroupVersionResource) (metav1.APIResource, error) {
+	list, err := disco.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return metav1.APIResource{}, fmt.Errorf("failed to discover %s: %w", gvrString(gvr), err)
+	}
+	for _, resource := range list.APIResources {
+		if resource.Name == gvr.Resource {
+			return resource, nil
+		}
+	}
+	return metav1.APIResource{}, fmt.Errorf("the server does not serve %s", gvrString(gvr))
+}
+
+func processObject(clusterName string, mapping jsonmap.Mapping, object unstructured.Unstructured) (api.ResourceProviderResource, error) {
+	data := maps.Clone(object.Object)
+	data["cluster"] = map[string]any{"name": clusterName}
+
+	resource, err := mapping.Apply(data)
+	if err != nil {
+		return resource, err
+	}
+
+	metadata := objectMetadata(object)
+	maps.Copy(metadata, resource.Metadata)
+	resource.Metadata = metadata
+	return resource, nil
+}
+
+// objectMetadata describes the labels, annotations, owners and status
+// conditions every Kubernetes object shares.
+func objectMetadata(object unstructured.Unstructured) map[string]string {
+	metadata := map[string]string{}
+	for key, value := range object.GetLabels() {
+		metadata[fmt.Sprintf("tags/%s", key)] = value
+	}
+	for key, value := range object.GetAnnotations() {
+		metadata[fmt.Sprintf("kubernetes/annotations/%s", key)] = value
+	}
+	metadata["kubernetes/uid"] = string(object.GetUID())
+	metadata["kubernetes/api-version"] = object.GetAPIVersion()
+	metadata["kubernetes/kind"] = object.GetKind()
+	metadata["kubernetes/created-at"] = object.GetCreationTimestamp().String()
+	if namespace := object.GetNamespace(); namespace != "" {
+		metadata["kubernetes/namespace"] = namespace
+	}
+
+	owners := object.GetOwnerReferences()
+	if len(owners) > 0 {
+		owner := owners[0]
+		if controller := metav1.GetControllerOfNoCopy(&object); controller != nil {
+			owner = *controller
+		}
+		metadata["kubernetes/owner-kind"] = owner.Kind
+		metadata["kubernetes/owner-name"] = owner.Name
+		metadata["kubernetes/owner-uid"] = string(owner.UID)
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, raw := range conditions {
+		condition, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		conditionType, _ := condition["type"].(string)
+		status, _ := condition["status"].(string)
+		if conditionType != "" {
+			metadata[fmt.Sprintf("kubernetes/condition/%s", conditionType)] = status
+		}
+	}
+
+	return metadata
+}
+
+func objectKey(object unstructured.Unstructured) string {
+	if object.GetNamespace() == "" {
+		return object.GetName()
+	}
+	return object.GetNamespace() + "/" + object.GetName()
+}
@@ -0,0 +1,173 @@
+package kubernetes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/kubeobject/kubeobjecttest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	statefulSets   = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	certificates   = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	clusterIssuers = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}
+)
+
+func newFakeClients(objects ...runtime.Object) (*fakedynamic.FakeDynamicClient, *fakediscovery.FakeDiscovery) {
+	listKinds := map[schema.GroupVersionResource]string{
+		statefulSets:   "StatefulSetList",
+		certificates:   "CertificateList",
+		clusterIssuers: "ClusterIssuerList",
+	}
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	disco := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "statefulsets", Kind: "StatefulSet", Namespaced: true}}},
+		{GroupVersion: "cert-manager.io/v1", APIResources: []metav1.APIResource{
+			{Name: "certificates", Kind: "Certificate", Namespaced: true},
+			{Name: "clusterissuers", Kind: "ClusterIssuer"},
+		}},
+	}}}
+	return client, disco
+}
+
+func TestParseGroupVersionResource(t *testing.T) {
+	gvr, err := ParseGroupVersionResource("v1/services")
+	if err != nil || gvr != (schema.GroupVersionResource{Version: "v1", Resource: "services"}) {
+		t.Fatalf("unexpected core resource %v, %v", gvr, err)
+	}
+	gvr, err = ParseGroupVersionResource("argoproj.io/v1alpha1/rollouts")
+	if err != nil || gvr.Group != "argoproj.io" || gvr.Resource != "rollouts" {
+		t.Fatalf("unexpected resource %v, %v", gvr, err)
+	}
+	for _, invalid := range []string{"services", "apps//statefulsets", "a/b/c/d"} {
+		if _, err := ParseGroupVersionResource(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestFetchResources_DefaultMapping(t *testing.T) {
+	web := kubeobjecttest.NewObject("apps/v1", "StatefulSet", "prod", "web", nil)
+	web.SetLabels(map[string]string{"app": "web"})
+	web.SetAnnotations(map[string]string{"team": "payments"})
+	web.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Rollout", Name: "web-rollout", UID: "owner"}})
+	unstructured.SetNestedSlice(web.Object, []any{
+		map[string]any{"type": "Available", "status": "True"},
+	}, "status", "conditions")
+
+	client, disco := newFakeClients(web)
+	s := newSync("", "", nil, nil, "cluster-a")
+	if err := s.FetchResources(context.Background(), client, disco, GroupVersionResources{statefulSets}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(s.resources))
+	}
+
+	resource := s.resources[0]
+	if resource.Name != "cluster-a/prod/web" || resource.Identifier != "uid-web" {
+		t.Errorf("unexpected name or identifier: %q %q", resource.Name, resource.Identifier)
+	}
+	if resource.Kind != "KubernetesStatefulSet" || resource.Version != "ctrlplane.dev/kubernetes/statefulset/v1" {
+		t.Errorf("unexpected kind or version: %q %q", resource.Kind, resource.Version)
+	}
+	if resource.Config["namespace"] != "prod" {
+		t.Errorf("expected namespace in config, got %#v", resource.Config)
+	}
+	expected := map[string]string{
+		"tags/app":                       "web",
+		"kubernetes/annotations/team":    "payments",
+		"kubernetes/owner-kind":          "Rollout",
+		"kubernetes/owner-name":          "web-rollout",
+		"kubernetes/condition/Available": "True",
+		"kubernetes/namespace":           "prod",
+	}
+	for key, value := range expected {
+		if resource.Metadata[key] != value {
+			t.Errorf("metadata %s = %q, want %q", key, resource.Metadata[key], value)
+		}
+	}
+}
+
+func TestFetchResources_Template(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	os.WriteFile(path, []byte(`
+metadata:
+  owner: "{.metadata.labels.owner}"
+resources:
+  cert-manager.io/v1/certificates:
+    name: "{.spec.commonName}"
+    kind: Certificate
+    config:
+      dnsNames: "{.spec.dnsNames}"
+`), 0o644)
+	template, err := LoadTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := kubeobjecttest.NewObject("cert-manager.io/v1", "Certificate", "prod", "api-tls", map[string]any{
+		"spec": map[string]any{"commonName": "api.example.com", "dnsNames": []any{"api.example.com"}},
+	})
+	cert.SetLabels(map[string]string{"owner": "platform"})
+
+	client, disco := newFakeClients(cert)
+	s := newSync("", "", nil, nil, "cluster-a")
+	if err := s.FetchResources(context.Background(), client, disco, GroupVersionResources{certificates}, template); err != nil {
+		t.Fatal(err)
+	}
+
+	resource := s.resources[0]
+	want := api.ResourceProviderResource{Name: "api.example.com", Kind: "Certificate", Identifier: "uid-api-tls"}
+	if resource.Name != want.Name || resource.Kind != want.Kind || resource.Identifier != want.Identifier {
+		t.Errorf("unexpected resource %+v", resource)
+	}
+	if names, ok := resource.Config["dnsNames"].([]any); !ok || len(names) != 1 {
+		t.Errorf("expected dnsNames to keep its type, got %#v", resource.Config["dnsNames"])
+	}
+	if resource.Metadata["owner"] != "platform" || resource.Metadata["tags/owner"] != "platform" {
+		t.Errorf("expected template metadata on top of the defaults, got %#v", resource.Metadata)
+	}
+}
+
+func TestFetchResources_NamespaceFilter(t *testing.T) {
+	client, disco := newFakeClients(
+		kubeobjecttest.NewObject("cert-manager.io/v1", "Certificate", "prod", "api-tls", nil),
+		kubeobjecttest.NewObject("cert-manager.io/v1", "Certificate", "staging", "api-tls", nil),
+		kubeobjecttest.NewObject("cert-manager.io/v1", "ClusterIssuer", "", "letsencrypt", nil),
+	)
+	s := newSync("", "", nil, nil, "cluster-a")
+	s.filter.Namespaces = []string{"prod"}
+	if err := s.FetchResources(context.Background(), client, disco, GroupVersionResources{certificates, clusterIssuers}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cluster-scoped objects are synced whatever --namespace says.
+	var names []string
+	for _, resource := range s.resources {
+		names = append(names, resource.Name)
+	}
+	if want := []string{"cluster-a/prod/api-tls", "cluster-a/letsencrypt"}; !slices.Equal(names, want) {
+		t.Errorf("synced %v, want %v", names, want)
+	}
+}
+
+func TestFetchResources_UnknownResource(t *testing.T) {
+	client, disco := newFakeClients()
+	s := newSync("", "", nil, nil, "cluster-a")
+	rollouts := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	if err := s.FetchResources(context.Background(), client, disco, GroupVersionResources{rollouts}, nil); err == nil {
+		t.Fatal("expected an error for a resource the server does not serve")
+	}
+}
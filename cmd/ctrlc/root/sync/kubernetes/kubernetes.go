@@ -1,67 +1,209 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
 	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
+	"github.com/ctrlplanedev/cli/internal/kubefilter"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
+// options are the flags shared by `sync kubernetes` and its controller.
+type options struct {
+	providerName      string
+	clusterIdentifier string
+	clusterName       string
+	selectors         ResourceTypes
+	gvrs              GroupVersionResources
+	templatePath      string
+	filter            kubefilter.Filter
+	contexts          kubeconfig.ContextFlags
+	watch             watchOptions
+	// metadata is set on every synced object; it is not a flag.
+	metadata map[string]string
+}
+
+func (o *options) addFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.providerName, "provider", "p", "", "Name of the resource provider ({context} is replaced with the kubeconfig context)")
+	flags.StringVarP(&o.clusterIdentifier, "cluster-identifier", "c", "", "The identifier of the parent cluster in ctrlplane (if not provided, will use the CLUSTER_IDENTIFIER environment variable; {context} is replaced with the kubeconfig context)")
+	flags.StringVarP(&o.clusterName, "cluster-name", "n", "", "The name of the cluster (default is the kubeconfig context)")
+	flags.VarP(&o.selectors, "selector", "s", "Select resources to sync [nodes|deployments|namespaces]. Repeat the flag to select multiple resources; omit it to sync all resources.")
+	flags.Var(&o.gvrs, "resource", "Sync any resource kind, including CRDs, as group/version/resource (e.g. apps/v1/statefulsets, v1/services). Repeat the flag for multiple kinds; built-in selectors are skipped unless --selector is also set.")
+	flags.StringVar(&o.templatePath, "template", "", "Path to a YAML file of JSONPath templates mapping --resource objects to resources")
+	o.filter.AddFlags(flags)
+	flags.StringVar(&o.filter.FieldSelector, "field-selector", "", "Only sync objects matching this field selector, e.g. metadata.name!=kube-system")
+	o.contexts.AddFlags(flags)
+	flags.DurationVar(&o.watch.debounce, "debounce", 10*time.Second, "Collect changes this long before pushing them in watch mode")
+	flags.DurationVar(&o.watch.resync, "resync", time.Hour, "Push the complete cached set this often in watch mode, even when unchanged, to repair drift (0 = only on changes)")
+}
+
 func NewSyncKubernetesCmd() *cobra.Command {
-	var clusterIdentifier string
-	var providerName string
-	var clusterName string
-	var selectors ResourceTypes
+	var opts options
+	var watch bool
 
 	cmd := &cobra.Command{
 		Use:   "kubernetes",
 		Short: "Sync Kubernetes resources on a cluster",
 		Example: heredoc.Doc(`
 			$ ctrlc sync kubernetes --cluster-identifier 1234567890 --cluster-name my-cluster
+
+			# Sync every kubeconfig context to its own provider
+			$ ctrlc sync kubernetes --all-contexts --provider "k8s-{context}" --cluster-identifier "{context}"
+
+			# Skip system namespaces and anything not labelled for ctrlplane;
+			# objects annotated ctrlplane.dev/sync: "false" are always skipped
+			$ ctrlc sync kubernetes --exclude-namespace kube-system --exclude-namespace kube-public \
+			    --label-selector ctrlplane.dev/managed=true
+
+			# Sync StatefulSets, Services and Argo Rollouts with a custom mapping
+			$ ctrlc sync kubernetes --resource apps/v1/statefulsets --resource v1/services \
+			    --resource argoproj.io/v1alpha1/rollouts --template mapping.yaml
+
+			# mapping.yaml
+			metadata:
+			  team: "{.metadata.labels.team}"
+			resources:
+			  argoproj.io/v1alpha1/rollouts:
+			    config:
+			      strategy: "{.spec.strategy}"
+
+			# Stream changes instead of listing the cluster on an interval
+			$ ctrlc sync kubernetes --watch --debounce 10s --resync 1h
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval, _ := cmd.Flags().GetString("interval"); watch && interval != "" {
+				return fmt.Errorf("--watch cannot be combined with --interval")
+			}
 			ctx := cmd.Context()
-			log.Info("Syncing Kubernetes resources on a cluster")
-			if clusterIdentifier == "" {
-				clusterIdentifier = viper.GetString("cluster-identifier")
+			if watch {
+				var stop context.CancelFunc
+				ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
 			}
+			return opts.run(ctx, watch)
+		},
+	}
+	opts.addFlags(cmd.Flags())
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep running and push changes as they happen, using informers instead of listing the cluster")
 
-			config, configClusterName, err := getKubeConfig()
-			if err != nil {
-				return err
-			}
+	cmd.AddCommand(NewControllerCmd())
+	cmd.AddCommand(NewManifestsCmd())
 
-			if clusterName == "" {
-				clusterName = configClusterName
-			}
+	return cmd
+}
 
-			log.Info("Connected to cluster", "name", clusterName)
+// run syncs the cluster once, or keeps watching it until ctx is cancelled.
+// With --context or --all-contexts every selected cluster is synced
+// concurrently to its own provider.
+func (o *options) run(ctx context.Context, watch bool) error {
+	log.Info("Syncing Kubernetes resources on a cluster")
+	var template *Template
+	if o.templatePath != "" {
+		t, err := LoadTemplate(o.templatePath)
+		if err != nil {
+			return err
+		}
+		template = t
+	}
 
-			apiURL := viper.GetString("url")
-			apiKey := viper.GetString("api-key")
-			workspaceId := viper.GetString("workspace")
+	if !o.contexts.Multi() {
+		config, configClusterName, err := kubeconfig.Load()
+		if err != nil {
+			return err
+		}
+		clusterIdentifier := o.clusterIdentifier
+		if clusterIdentifier == "" {
+			clusterIdentifier = viper.GetString("cluster-identifier")
+		}
+		clusterName := o.clusterName
+		if clusterName == "" {
+			clusterName = configClusterName
+		}
+		return o.runCluster(ctx, watch, config, template, clusterIdentifier, clusterName, o.providerName)
+	}
 
-			ctrlplaneClient, err := api.NewAPIKeyClientWithResponses(apiURL, apiKey)
-			if err != nil {
-				return fmt.Errorf("failed to create API client: %w", err)
-			}
-			sync := newSync(clusterIdentifier, workspaceId, ctrlplaneClient, config, clusterName)
-			resources, err := sync.process(ctx, selectors)
-			if err != nil {
-				return err
+	contexts, err := o.contexts.LoadContexts()
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 1 {
+		for flag, value := range map[string]string{"provider": o.providerName, "cluster-identifier": o.clusterIdentifier} {
+			if value != "" && !strings.Contains(value, "{context}") {
+				return fmt.Errorf("--%s must contain {context} when syncing several contexts", flag)
 			}
+		}
+	}
+	// Watches never return, so each context needs its own slot.
+	concurrency := o.contexts.Concurrency
+	if watch {
+		concurrency = len(contexts)
+	}
+	return kubeconfig.ForEach(ctx, contexts, concurrency, func(ctx context.Context, c kubeconfig.Context) error {
+		clusterName := kubeconfig.Expand(o.clusterName, c.Name)
+		if clusterName == "" {
+			clusterName = c.Name
+		}
+		return o.runCluster(ctx, watch, c.Config, template,
+			kubeconfig.Expand(o.clusterIdentifier, c.Name), clusterName, kubeconfig.Expand(o.providerName, c.Name))
+	})
+}
 
-			return ctrlp.UpsertResources(ctx, resources, &providerName)
-		},
+func (o *options) runCluster(ctx context.Context, watch bool, config *rest.Config, template *Template, clusterIdentifier, clusterName, providerName string) error {
+	log.Info("Connected to cluster", "name", clusterName)
+
+	apiURL := viper.GetString("url")
+	apiKey := viper.GetString("api-key")
+	workspaceId := viper.GetString("workspace")
+
+	ctrlplaneClient, err := api.NewAPIKeyClientWithResponses(apiURL, apiKey)
+	if err != nil {
+		return fmt.Errorf("failed to create API client: %w", err)
+	}
+	sync := newSync(clusterIdentifier, workspaceId, ctrlplaneClient, config, clusterName)
+	sync.filter = o.filter
+	if watch {
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return err
+		}
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return err
+		}
+		return sync.watch(ctx, clientset, dynamicClient, o.selectors, o.gvrs, template, o.watch,
+			func(ctx context.Context, resources []api.ResourceProviderResource, resync bool) error {
+				o.setMetadata(resources)
+				return ctrlp.UpsertResources(ctx, resources, &providerName, ctrlp.WithForce(resync))
+			})
 	}
-	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Name of the resource provider")
-	cmd.Flags().StringVarP(&clusterIdentifier, "cluster-identifier", "c", "", "The identifier of the parent cluster in ctrlplane (if not provided, will use the CLUSTER_IDENTIFIER environment variable)")
-	cmd.Flags().StringVarP(&clusterName, "cluster-name", "n", "", "The name of the cluster")
-	cmd.Flags().VarP(&selectors, "selector", "s", "Select resources to sync [nodes|deployments|namespaces]. Repeat the flag to select multiple resources; omit it to sync all resources.")
 
-	return cmd
+	resources, err := sync.process(ctx, o.selectors, o.gvrs, template)
+	if err != nil {
+		return err
+	}
+
+	o.setMetadata(resources)
+	return ctrlp.UpsertResources(ctx, resources, &providerName)
+}
+
+func (o *options) setMetadata(resources []api.ResourceProviderResource) {
+	for _, resource := range resources {
+		maps.Copy(resource.Metadata, o.metadata)
+	}
 }
@@ -0,0 +1,225 @@
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// healthPort is the container port of the controller's --health-address.
+const healthPort = 8081
+
+type manifestOptions struct {
+	name              string
+	namespace         string
+	image             string
+	replicas          int32
+	secret            string
+	providerName      string
+	clusterIdentifier string
+	selectors         ResourceTypes
+	gvrs              GroupVersionResources
+	watch             bool
+	helm              bool
+}
+
+func NewManifestsCmd() *cobra.Command {
+	var opts manifestOptions
+
+	cmd := &cobra.Command{
+		Use:   "manifests",
+		Short: "Print the RBAC and Deployment manifests for the in-cluster controller",
+		Long: heredoc.Doc(`
+			Prints a ServiceAccount, the ClusterRole and binding granting read
+			access to exactly the resources the controller syncs, a Role for its
+			leader election Lease, and a Deployment running
+			"ctrlc sync kubernetes controller".
+
+			The Deployment reads CTRLPLANE_URL, CTRLPLANE_API_KEY and
+			CTRLPLANE_WORKSPACE from the Secret named by --secret, which is not
+			generated.
+		`),
+		Example: heredoc.Doc(`
+			$ kubectl -n ctrlplane create secret generic ctrlc-sync-credentials \
+			    --from-literal=CTRLPLANE_URL=https://app.ctrlplane.dev \
+			    --from-literal=CTRLPLANE_API_KEY=... --from-literal=CTRLPLANE_WORKSPACE=my-workspace
+			$ ctrlc sync kubernetes manifests --provider prod-k8s --cluster-identifier prod \
+			    --watch --helm --resource apps/v1/statefulsets | kubectl apply -f -
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.secret == "" {
+				opts.secret = opts.name + "-credentials"
+			}
+			out, err := renderManifests(buildManifests(opts))
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "ctrlc-sync", "Name of the generated objects")
+	cmd.Flags().StringVar(&opts.namespace, "namespace", "ctrlplane", "Namespace to run the controller in")
+	cmd.Flags().StringVar(&opts.image, "image", "ctrlplane/cli:latest", "Container image of the controller")
+	cmd.Flags().Int32Var(&opts.replicas, "replicas", 2, "Number of controller replicas; one leads at a time")
+	cmd.Flags().StringVar(&opts.secret, "secret", "", "Secret holding the Ctrlplane URL, API key and workspace (default is <name>-credentials)")
+	cmd.Flags().StringVarP(&opts.providerName, "provider", "p", "", "Name of the resource provider")
+	cmd.Flags().StringVarP(&opts.clusterIdentifier, "cluster-identifier", "c", "", "The identifier of the parent cluster in ctrlplane")
+	cmd.Flags().VarP(&opts.selectors, "selector", "s", "Select resources to sync [nodes|deployments|namespaces]. Repeat the flag to select multiple resources; omit it to sync all resources.")
+	cmd.Flags().Var(&opts.gvrs, "resource", "Also sync and grant access to this group/version/resource (repeatable)")
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "Run the controller in watch mode")
+	cmd.Flags().BoolVar(&opts.helm, "helm", false, "Also sync Helm releases, which needs read access to Secrets")
+	cmd.MarkFlagRequired("provider")
+
+	return cmd
+}
+
+// buildManifests returns the objects needed to run the controller with the
+// least privilege for the selected resources.
+func buildManifests(opts manifestOptions) []runtime.Object {
+	labels := map[string]string{"app.kubernetes.io/name": opts.name}
+	meta := metav1.ObjectMeta{Name: opts.name, Namespace: opts.namespace, Labels: labels}
+	clusterMeta := metav1.ObjectMeta{Name: opts.name, Labels: labels}
+	read := []string{"get", "list", "watch"}
+
+	var rules []rbacv1.PolicyRule
+	if fetchBuiltins(opts.selectors, opts.gvrs) {
+		var core []string
+		if opts.selectors.ShouldFetch(ResourceNamespace) {
+			core = append(core, "namespaces")
+		}
+		if opts.selectors.ShouldFetch(ResourceNode) {
+			core = append(core, "nodes")
+		}
+		if len(core) > 0 {
+			rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: core, Verbs: read})
+		}
+		if opts.selectors.ShouldFetch(ResourceDeployment) {
+			rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: read})
+		}
+	}
+	for _, gvr := range opts.gvrs {
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{gvr.Group}, Resources: []string{gvr.Resource}, Verbs: read})
+	}
+	if opts.helm {
+		// Helm stores releases in Secrets.
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: read})
+	}
+
+	args := []string{"sync", "kubernetes", "controller", "--provider", opts.providerName}
+	if opts.clusterIdentifier != "" {
+		args = append(args, "--cluster-identifier", opts.clusterIdentifier)
+	}
+	for _, selector := range opts.selectors {
+		args = append(args, "--selector", selector.String())
+	}
+	for _, gvr := range opts.gvrs {
+		args = append(args, "--resource", gvrString(gvr))
+	}
+	if opts.watch {
+		args = append(args, "--watch")
+	}
+	if opts.helm {
+		args = append(args, "--helm")
+	}
+	args = append(args, "--lease-name", opts.name, "--health-address", fmt.Sprintf(":%d", healthPort))
+
+	probe := func(path string) *corev1.Probe {
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromString("health")},
+			},
+			PeriodSeconds: 10,
+		}
+	}
+
+	return []runtime.Object{
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: meta,
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: clusterMeta,
+			Rules:      rules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: clusterMeta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: opts.name},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: opts.name, Namespace: opts.namespace}},
+		},
+		&rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: meta,
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"get", "create", "update"},
+			}},
+		},
+		&rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+			ObjectMeta: meta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: opts.name},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: opts.name, Namespace: opts.namespace}},
+		},
+		&appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &opts.replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						ServiceAccountName: opts.name,
+						Containers: []corev1.Container{{
+							Name:  "ctrlc",
+							Image: opts.image,
+							Args:  args,
+							EnvFrom: []corev1.EnvFromSource{{
+								SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: opts.secret}},
+							}},
+							Env: []corev1.EnvVar{{
+								Name: "POD_NAMESPACE",
+								ValueFrom: &corev1.EnvVarSource{
+									FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+								},
+							}},
+							Ports:          []corev1.ContainerPort{{Name: "health", ContainerPort: healthPort}},
+							LivenessProbe:  probe("/healthz"),
+							ReadinessProbe: probe("/readyz"),
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// renderManifests prints the objects as one multi-document YAML stream.
+func renderManifests(objects []runtime.Object) ([]byte, error) {
+	var out bytes.Buffer
+	for i, object := range objects {
+		data, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render manifest: %w", err)
+		}
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(data)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestBuildManifests(t *testing.T) {
+	objects := buildManifests(manifestOptions{
+		name:      "ctrlc-sync",
+		namespace: "ctrlplane",
+		image:     "ctrlplane/cli:v1",
+		replicas:  2,
+		secret:    "creds",
+		selectors: ResourceTypes{ResourceDeployment},
+		gvrs:      GroupVersionResources{statefulSets},
+		watch:     true,
+		helm:      true,
+	})
+
+	var role *rbacv1.ClusterRole
+	var deployment *appsv1.Deployment
+	for _, object := range objects {
+		switch o := object.(type) {
+		case *rbacv1.ClusterRole:
+			role = o
+		case *appsv1.Deployment:
+			deployment = o
+		}
+	}
+	if role == nil || deployment == nil {
+		t.Fatalf("expected a ClusterRole and a Deployment, got %d objects", len(objects))
+	}
+
+	var granted []string
+	for _, rule := range role.Rules {
+		for _, resource := range rule.Resources {
+			granted = append(granted, rule.APIGroups[0]+"/"+resource)
+		}
+		if !slices.Equal(rule.Verbs, []string{"get", "list", "watch"}) {
+			t.Errorf("expected read-only verbs, got %v", rule.Verbs)
+		}
+	}
+	want := []string{"apps/deployments", "apps/statefulsets", "/secrets"}
+	if !slices.Equal(granted, want) {
+		t.Errorf("granted %v, want %v", granted, want)
+	}
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	args := strings.Join(container.Args, " ")
+	for _, arg := range []string{"sync kubernetes controller", "--selector deployments", "--resource apps/v1/statefulsets", "--watch", "--helm"} {
+		if !strings.Contains(args, arg) {
+			t.Errorf("expected %q in args %q", arg, args)
+		}
+	}
+	if container.EnvFrom[0].SecretRef.Name != "creds" {
+		t.Errorf("expected credentials from secret creds, got %+v", container.EnvFrom)
+	}
+
+	out, err := renderManifests(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if documents := strings.Count(string(out), "\n---\n") + 1; documents != len(objects) {
+		t.Errorf("expected %d documents, got %d", len(objects), documents)
+	}
+}
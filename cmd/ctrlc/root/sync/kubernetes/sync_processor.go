@@ -7,9 +7,10 @@ import (
 
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/kubefilter"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -20,16 +21,20 @@ type syncConfig struct {
 	clusterName       string
 	kubeConfig        *rest.Config
 	client            *api.ClientWithResponses
+	filter            kubefilter.Filter
 	resources         []api.ResourceProviderResource
 }
 
-func (s *syncConfig) FetchNodes(ctx context.Context, clientset *kubernetes.Clientset) error {
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+func (s *syncConfig) FetchNodes(ctx context.Context, clientset kubernetes.Interface) error {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, s.filter.ListOptions())
 	if err != nil {
 		return err
 	}
 	resources := make([]api.ResourceProviderResource, 0)
 	for _, node := range nodes.Items {
+		if !s.filter.Includes(&node) {
+			continue
+		}
 		processedNode := processNode(ctx, s.clusterName, node)
 		resources = append(resources, processedNode)
 	}
@@ -37,14 +42,17 @@ func (s *syncConfig) FetchNodes(ctx context.Context, clientset *kubernetes.Clien
 	return nil
 }
 
-func (s *syncConfig) FetchNamespaces(ctx context.Context, clientset *kubernetes.Clientset) error {
-	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+func (s *syncConfig) FetchNamespaces(ctx context.Context, clientset kubernetes.Interface) error {
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, s.filter.ListOptions())
 	if err != nil {
 		return err
 	}
 
 	resources := make([]api.ResourceProviderResource, 0)
 	for _, namespace := range namespaces.Items {
+		if !s.filter.Includes(&namespace) || !s.filter.IncludesNamespace(namespace.Name) {
+			continue
+		}
 		resource := processNamespace(context.Background(), s.clusterName, namespace)
 		resources = append(resources, resource)
 	}
@@ -53,15 +61,20 @@ func (s *syncConfig) FetchNamespaces(ctx context.Context, clientset *kubernetes.
 
 }
 
-func (s *syncConfig) FetchDeployments(ctx context.Context, clientset *kubernetes.Clientset) error {
-	deployments, err := clientset.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return err
-	}
+func (s *syncConfig) FetchDeployments(ctx context.Context, clientset kubernetes.Interface) error {
 	resources := make([]api.ResourceProviderResource, 0)
-	for _, deployment := range deployments.Items {
-		resource := processDeployment(context.Background(), s.clusterName, deployment)
-		resources = append(resources, resource)
+	for _, namespace := range s.filter.Scopes() {
+		deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, s.filter.ListOptions())
+		if err != nil {
+			return err
+		}
+		for _, deployment := range deployments.Items {
+			if !s.filter.Includes(&deployment) {
+				continue
+			}
+			resource := processDeployment(context.Background(), s.clusterName, deployment)
+			resources = append(resources, resource)
+		}
 	}
 	s.resources = append(s.resources, resources...)
 	return nil
@@ -171,7 +184,9 @@ func processDeployment(_ context.Context, clusterName string, deployment appsv1.
 	}
 }
 
-func (s *syncConfig) process(ctx context.Context, selectors ResourceTypes) ([]api.ResourceProviderResource, error) {
+// fetchCluster looks up the parent cluster resource in ctrlplane. It returns
+// nil when the cluster is not registered, and adopts its name otherwise.
+func (s *syncConfig) fetchCluster(ctx context.Context) (*api.Resource, error) {
 	clusterResource, err := s.client.GetResourceByIdentifierWithResponse(ctx, s.workspaceID, s.clusterIdentifier)
 	if err != nil {
 		log.Warn("Failed to get cluster resource", "identifier", s.clusterIdentifier, "error", err)
@@ -181,9 +196,37 @@ func (s *syncConfig) process(ctx context.Context, selectors ResourceTypes) ([]ap
 		return nil, fmt.Errorf("error access ctrlplane api: %s", clusterResource.Status())
 	}
 
-	if clusterResource != nil && clusterResource.JSON200 != nil {
-		log.Info("Found cluster resource", "name", clusterResource.JSON200.Name)
-		s.clusterName = clusterResource.JSON200.Name
+	if clusterResource == nil || clusterResource.JSON200 == nil {
+		return nil, nil
+	}
+	log.Info("Found cluster resource", "name", clusterResource.JSON200.Name)
+	s.clusterName = clusterResource.JSON200.Name
+	return clusterResource.JSON200, nil
+}
+
+// inheritClusterMetadata copies the cluster's non-tag metadata onto every
+// synced resource that does not set the key itself.
+func inheritClusterMetadata(resources []api.ResourceProviderResource, cluster *api.Resource) {
+	if cluster == nil {
+		return
+	}
+	for _, resource := range resources {
+		for key, value := range cluster.Metadata {
+			if strings.HasPrefix(key, "tags/") {
+				continue
+			}
+			if _, exists := resource.Metadata[key]; !exists {
+				resource.Metadata[key] = value
+			}
+		}
+		resource.Metadata["kubernetes/name"] = cluster.Name
+	}
+}
+
+func (s *syncConfig) process(ctx context.Context, selectors ResourceTypes, gvrs GroupVersionResources, template *Template) ([]api.ResourceProviderResource, error) {
+	cluster, err := s.fetchCluster(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	clientset, err := kubernetes.NewForConfig(s.kubeConfig)
@@ -191,37 +234,43 @@ func (s *syncConfig) process(ctx context.Context, selectors ResourceTypes) ([]ap
 		return nil, err
 	}
 
-	if selectors.ShouldFetch(ResourceNamespace) {
+	builtins := fetchBuiltins(selectors, gvrs)
+
+	if builtins && selectors.ShouldFetch(ResourceNamespace) {
 		if err := s.FetchNamespaces(ctx, clientset); err != nil {
 			return s.resources, err
 		}
 	}
 
-	if selectors.ShouldFetch(ResourceDeployment) {
+	if builtins && selectors.ShouldFetch(ResourceDeployment) {
 		if err := s.FetchDeployments(ctx, clientset); err != nil {
 			return s.resources, err
 		}
 	}
 
-	if selectors.ShouldFetch(ResourceNode) {
+	if builtins && selectors.ShouldFetch(ResourceNode) {
 		if err := s.FetchNodes(ctx, clientset); err != nil {
 			return s.resources, err
 		}
 	}
 
-	if clusterResource != nil && clusterResource.JSON200 != nil {
-		for _, resource := range s.resources {
-			for key, value := range clusterResource.JSON200.Metadata {
-				if strings.HasPrefix(key, "tags/") {
-					continue
-				}
-				if _, exists := resource.Metadata[key]; !exists {
-					resource.Metadata[key] = value
-				}
-			}
-			resource.Metadata["kubernetes/name"] = clusterResource.JSON200.Name
+	if len(gvrs) > 0 {
+		dynamicClient, err := dynamic.NewForConfig(s.kubeConfig)
+		if err != nil {
+			return s.resources, err
+		}
+		if err := s.FetchResources(ctx, dynamicClient, clientset.Discovery(), gvrs, template); err != nil {
+			return s.resources, err
 		}
 	}
 
+	inheritClusterMetadata(s.resources, cluster)
 	return s.resources, nil
 }
+
+// fetchBuiltins reports whether the namespace, deployment and node syncs run.
+// --resource on its own replaces the built-in selectors instead of adding to
+// all of them.
+func fetchBuiltins(selectors ResourceTypes, gvrs GroupVersionResources) bool {
+	return len(selectors) > 0 || len(gvrs) == 0
+}
@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/kubefilter"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFetch_Filter(t *testing.T) {
+	namespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)}}
+	}
+	deployment := func(namespace, name string, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace, Name: name, UID: types.UID(namespace + "/" + name), Annotations: annotations,
+		}}
+	}
+	clientset := fake.NewSimpleClientset(
+		namespace("prod"), namespace("staging"), namespace("kube-system"),
+		deployment("prod", "web", nil),
+		deployment("prod", "debug", map[string]string{kubefilter.OptOut: "false"}),
+		deployment("staging", "web", nil),
+		deployment("kube-system", "coredns", nil),
+	)
+
+	tests := []struct {
+		name   string
+		filter kubefilter.Filter
+		want   []string
+	}{
+		{
+			name:   "exclude",
+			filter: kubefilter.Filter{ExcludeNamespaces: []string{"kube-system"}},
+			want:   []string{"prod", "staging", "prod/web", "staging/web"},
+		},
+		{
+			name:   "include",
+			filter: kubefilter.Filter{Namespaces: []string{"prod"}},
+			want:   []string{"prod", "prod/web"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSync("", "", nil, nil, "cluster-a")
+			s.filter = tt.filter
+			if err := s.FetchNamespaces(context.Background(), clientset); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.FetchDeployments(context.Background(), clientset); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, resource := range s.resources {
+				got = append(got, resource.Identifier)
+			}
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
 	"github.com/ctrlplanedev/cli/internal/kinds"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
 	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
 
 	"github.com/loft-sh/vcluster/pkg/cli/find"
@@ -181,12 +182,16 @@ func getCreateResourceFromVcluster(vcluster find.VCluster, clusterResource Clust
 func NewSyncVclusterCmd() *cobra.Command {
 	var clusterIdentifier string
 	var providerName string
+	var workloads WorkloadOptions
 
 	cmd := &cobra.Command{
 		Use:   "vcluster",
 		Short: "Sync vcluster resources",
 		Example: heredoc.Doc(`
 			$ ctrlc sync vcluster
+
+			# Also sync the namespaces and deployments inside every running vcluster
+			$ ctrlc sync vcluster --with-workloads --workload-selector namespaces --workload-selector deployments
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			apiURL := viper.GetString("url")
@@ -211,7 +216,7 @@ func NewSyncVclusterCmd() *cobra.Command {
 				return fmt.Errorf("failed to get parent cluster resource: %w", err)
 			}
 
-			config, context, err := getKubeConfig()
+			config, context, err := kubeconfig.Load()
 			if err != nil {
 				return fmt.Errorf("failed to get kube config: %w", err)
 			}
@@ -251,12 +256,13 @@ func NewSyncVclusterCmd() *cobra.Command {
 
 			// createResourceRelationshipRule(cmd.Context(), rp, clusterResource)
 
-			return nil
+			return syncVclusterWorkloads(cmd.Context(), workloads, config, vclusters, resourcesToUpsert, clusterResource, providerName)
 		},
 	}
 
 	cmd.Flags().StringVarP(&clusterIdentifier, "cluster-identifier", "c", "", "The identifier of the parent cluster in ctrlplane (if not provided, will use the CLUSTER_IDENTIFIER environment variable)")
 	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "The name of the resource provider (optional)")
+	workloads.AddFlags(cmd.Flags())
 
 	return cmd
 }
@@ -0,0 +1,171 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/kinds"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	// vclusterSecretPrefix and vclusterSecretKey locate the kubeconfig
+	// vcluster writes for itself in the parent cluster.
+	vclusterSecretPrefix = "vc-"
+	vclusterSecretKey    = "config"
+	// defaultVclusterPort is the port the vcluster API server listens on in
+	// its pod when the kubeconfig does not say otherwise.
+	defaultVclusterPort = "8443"
+)
+
+// connectVcluster reads the kubeconfig vcluster stores in the vc-<name>
+// secret and port-forwards to the vcluster pod, the way `vcluster connect`
+// does. The returned configuration is valid until stop is called.
+func connectVcluster(ctx context.Context, config *rest.Config, clientset kubernetes.Interface, vcluster find.VCluster) (*rest.Config, func(), error) {
+	pod, err := vclusterPod(vcluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretName := vclusterSecretPrefix + vcluster.Name
+	secret, err := clientset.CoreV1().Secrets(vcluster.Namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get vcluster kubeconfig secret %s: %w", secretName, err)
+	}
+	raw, err := clientcmd.Load(secret.Data[vclusterSecretKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse vcluster kubeconfig: %w", err)
+	}
+	if len(raw.Clusters) != 1 {
+		return nil, nil, fmt.Errorf("expected one cluster in the vcluster kubeconfig, got %d", len(raw.Clusters))
+	}
+	remotePort := defaultVclusterPort
+	for _, cluster := range raw.Clusters {
+		if server, err := url.Parse(cluster.Server); err == nil && server.Port() != "" {
+			remotePort = server.Port()
+		}
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	request := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(vcluster.Namespace).
+		Name(pod).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, request.URL())
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"localhost"}, []string{"0:" + remotePort}, stopChan, readyChan, io.Discard, io.Discard)
+	if err != nil {
+		return nil, nil, err
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- forwarder.ForwardPorts() }()
+
+	select {
+	case err := <-errChan:
+		return nil, nil, fmt.Errorf("port-forwarding to pod %s: %w", pod, err)
+	case <-ctx.Done():
+		close(stopChan)
+		return nil, nil, ctx.Err()
+	case <-readyChan:
+	}
+	stop := func() { close(stopChan) }
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	for _, cluster := range raw.Clusters {
+		cluster.Server = "https://localhost:" + strconv.Itoa(int(ports[0].Local))
+	}
+	vclusterConfig, err := clientcmd.NewDefaultClientConfig(*raw, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	return vclusterConfig, stop, nil
+}
+
+// vclusterPod returns the newest running pod of the vcluster.
+func vclusterPod(vcluster find.VCluster) (string, error) {
+	pods := make([]corev1.Pod, 0, len(vcluster.Pods))
+	for _, pod := range vcluster.Pods {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return "", fmt.Errorf("no running pod found for vcluster %s/%s", vcluster.Namespace, vcluster.Name)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+	})
+	return pods[0].Name, nil
+}
+
+// vclusterWorkloadMetadata tags the objects synced inside a vcluster with the
+// vcluster and the cluster it runs in.
+func vclusterWorkloadMetadata(vcluster find.VCluster, parent ClusterResource) map[string]string {
+	return map[string]string{
+		kinds.VClusterMetadataName:              vcluster.Name,
+		kinds.VClusterMetadataNamespace:         vcluster.Namespace,
+		kinds.VClusterMetadataParentCluster:     parent.Identifier,
+		kinds.VClusterMetadataParentClusterName: parent.Name,
+	}
+}
+
+// syncVclusterWorkloads runs the workload sync inside every running vcluster.
+// Paused and sleeping vclusters have no API server to connect to and are
+// skipped. resources are the upserted vcluster resources, in the order of
+// vclusters.
+func syncVclusterWorkloads(ctx context.Context, opts WorkloadOptions, config *rest.Config, vclusters []find.VCluster, resources []api.ResourceProviderResource, parent ClusterResource, providerName string) error {
+	if !opts.Enabled {
+		return nil
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kube client: %w", err)
+	}
+
+	clusters := make([]Cluster, 0, len(vclusters))
+	for i, vcluster := range vclusters {
+		if status := getNormalizedVclusterStatus(vcluster.Status); status != "running" {
+			log.Info("Skipping vcluster workloads", "vcluster", vcluster.Name, "namespace", vcluster.Namespace, "status", status)
+			continue
+		}
+		vclusterConfig, stop, err := connectVcluster(ctx, config, clientset, vcluster)
+		if err != nil {
+			log.Error("Failed to connect to vcluster", "vcluster", vcluster.Name, "namespace", vcluster.Namespace, "error", err)
+			continue
+		}
+		defer stop()
+		clusters = append(clusters, Cluster{
+			Identifier: resources[i].Identifier,
+			Name:       resources[i].Name,
+			Provider:   fmt.Sprintf("%s-%s-%s", providerName, vcluster.Namespace, vcluster.Name),
+			Config:     vclusterConfig,
+			Metadata:   vclusterWorkloadMetadata(vcluster, parent),
+		})
+	}
+	return SyncWorkloads(ctx, opts, clusters)
+}
@@ -0,0 +1,64 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestVclusterPod(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, phase corev1.PodPhase, age time.Duration, deleting bool) corev1.Pod {
+		p := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		if deleting {
+			p.DeletionTimestamp = &metav1.Time{Time: now}
+		}
+		return p
+	}
+
+	name, err := vclusterPod(find.VCluster{Name: "dev", Namespace: "team-a", Pods: []corev1.Pod{
+		pod("dev-0-old", corev1.PodRunning, time.Hour, false),
+		pod("dev-0-new", corev1.PodRunning, time.Minute, false),
+		pod("dev-0-pending", corev1.PodPending, time.Second, false),
+		pod("dev-0-terminating", corev1.PodRunning, time.Second, true),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "dev-0-new" {
+		t.Errorf("pod = %q, want dev-0-new", name)
+	}
+
+	if _, err := vclusterPod(find.VCluster{Name: "dev", Namespace: "team-a"}); err == nil {
+		t.Error("expected an error for a vcluster without running pods")
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	o := options{metadata: vclusterWorkloadMetadata(
+		find.VCluster{Name: "dev", Namespace: "team-a"},
+		ClusterResource{Identifier: "prod-cluster", Name: "prod"},
+	)}
+	resources := []api.ResourceProviderResource{{Metadata: map[string]string{"vcluster/name": "inherited", "kubernetes/namespace": "web"}}}
+	o.setMetadata(resources)
+
+	want := map[string]string{
+		"vcluster/name":                "dev",
+		"vcluster/namespace":           "team-a",
+		"vcluster/parent-cluster":      "prod-cluster",
+		"vcluster/parent-cluster-name": "prod",
+		"kubernetes/namespace":         "web",
+	}
+	for key, value := range want {
+		if got := resources[0].Metadata[key]; got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
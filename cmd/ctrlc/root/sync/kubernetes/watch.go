@@ -0,0 +1,264 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+type watchOptions struct {
+	// debounce is how long changes are collected before the set is pushed.
+	debounce time.Duration
+	// resync is how often the complete set is pushed even without changes.
+	resync time.Duration
+}
+
+// watchSource is one informer and the conversion of its cached objects.
+// convert reports false for objects that should not be synced.
+type watchSource struct {
+	name     string
+	informer cache.SharedIndexInformer
+	convert  func(obj any) (api.ResourceProviderResource, bool, error)
+}
+
+// watch keeps an informer cache per selected resource and pushes the cached
+// set whenever objects are added, updated or deleted, at most once per
+// debounce window, until ctx is cancelled. The apiserver is only listed once
+// per informer; after that it streams changes, and the reflectors re-list on
+// their own when a watch fails or expires. Failed pushes are logged and
+// retried with the next change or resync.
+func (s *syncConfig) watch(ctx context.Context, clientset kubernetes.Interface, dynamicClient dynamic.Interface, selectors ResourceTypes, gvrs GroupVersionResources, template *Template, opts watchOptions, push func(ctx context.Context, resources []api.ResourceProviderResource, resync bool) error) error {
+	// One factory per namespace, so that --namespace watches only those
+	// namespaces and a namespace-scoped Role is enough.
+	tweak := func(options *metav1.ListOptions) {
+		filtered := s.filter.ListOptions()
+		options.LabelSelector = filtered.LabelSelector
+		options.FieldSelector = filtered.FieldSelector
+	}
+	factories := map[string]informers.SharedInformerFactory{}
+	factory := func(namespace string) informers.SharedInformerFactory {
+		if factories[namespace] == nil {
+			factories[namespace] = informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+				informers.WithNamespace(namespace), informers.WithTweakListOptions(tweak))
+		}
+		return factories[namespace]
+	}
+	dynamicFactories := map[string]dynamicinformer.DynamicSharedInformerFactory{}
+	dynamicFactory := func(namespace string) dynamicinformer.DynamicSharedInformerFactory {
+		if dynamicFactories[namespace] == nil {
+			dynamicFactories[namespace] = dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, namespace, tweak)
+		}
+		return dynamicFactories[namespace]
+	}
+
+	var sources []watchSource
+	if fetchBuiltins(selectors, gvrs) {
+		if selectors.ShouldFetch(ResourceNamespace) {
+			sources = append(sources, watchSource{
+				name:     ResourceNamespace.String(),
+				informer: factory(metav1.NamespaceAll).Core().V1().Namespaces().Informer(),
+				convert: func(obj any) (api.ResourceProviderResource, bool, error) {
+					namespace := obj.(*corev1.Namespace)
+					if !s.filter.IncludesNamespace(namespace.Name) {
+						return api.ResourceProviderResource{}, false, nil
+					}
+					return processNamespace(ctx, s.clusterName, *namespace), true, nil
+				},
+			})
+		}
+		if selectors.ShouldFetch(ResourceDeployment) {
+			for _, namespace := range s.filter.Scopes() {
+				sources = append(sources, watchSource{
+					name:     scopedName(ResourceDeployment.String(), namespace),
+					informer: factory(namespace).Apps().V1().Deployments().Informer(),
+					convert: func(obj any) (api.ResourceProviderResource, bool, error) {
+						return processDeployment(ctx, s.clusterName, *obj.(*appsv1.Deployment)), true, nil
+					},
+				})
+			}
+		}
+		if selectors.ShouldFetch(ResourceNode) {
+			sources = append(sources, watchSource{
+				name:     ResourceNode.String(),
+				informer: factory(metav1.NamespaceAll).Core().V1().Nodes().Informer(),
+				convert: func(obj any) (api.ResourceProviderResource, bool, error) {
+					return processNode(ctx, s.clusterName, *obj.(*corev1.Node)), true, nil
+				},
+			})
+		}
+	}
+	for _, gvr := range gvrs {
+		resource, err := discoverResource(clientset.Discovery(), gvr)
+		if err != nil {
+			return err
+		}
+		scopes := []string{metav1.NamespaceAll}
+		if resource.Namespaced {
+			scopes = s.filter.Scopes()
+		}
+		mapping := template.mappingFor(gvr, resource)
+		for _, namespace := range scopes {
+			sources = append(sources, watchSource{
+				name:     scopedName(gvrString(gvr), namespace),
+				informer: dynamicFactory(namespace).ForResource(gvr).Informer(),
+				convert: func(obj any) (api.ResourceProviderResource, bool, error) {
+					resource, err := processObject(s.clusterName, mapping, *obj.(*unstructured.Unstructured))
+					return resource, true, err
+				},
+			})
+		}
+	}
+
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	for _, source := range sources {
+		if err := source.informer.SetTransform(stripManagedFields); err != nil {
+			return err
+		}
+		name := source.name
+		if err := source.informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			log.Warn("Kubernetes watch failed, re-listing", "resource", name, "error", err)
+		}); err != nil {
+			return err
+		}
+		if _, err := source.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(any) { notify() },
+			UpdateFunc: func(any, any) { notify() },
+			DeleteFunc: func(any) { notify() },
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+		defer factory.Shutdown()
+	}
+	for _, factory := range dynamicFactories {
+		factory.Start(ctx.Done())
+		defer factory.Shutdown()
+	}
+
+	for _, source := range sources {
+		if !cache.WaitForCacheSync(ctx.Done(), source.informer.HasSynced) {
+			return ctx.Err()
+		}
+		log.Info("Watching Kubernetes resources", "resource", source.name, "count", len(source.informer.GetStore().ListKeys()))
+	}
+
+	cluster, err := s.fetchCluster(ctx)
+	if err != nil {
+		return err
+	}
+	// A resync pushes even when the set matches the last push, to repair
+	// changes made on the server.
+	sync := func(resync bool) {
+		resources, err := s.snapshot(sources)
+		if err == nil {
+			inheritClusterMetadata(resources, cluster)
+			err = push(ctx, resources, resync)
+		}
+		if err != nil {
+			log.Error("Sync failed, waiting for changes", "error", err)
+		}
+	}
+
+	// The initial list produced an add event per object; they are all in the
+	// first push.
+	select {
+	case <-changes:
+	default:
+	}
+	sync(false)
+
+	var resync <-chan time.Time
+	if opts.resync > 0 {
+		ticker := time.NewTicker(opts.resync)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+	// The timer starts with the first change of a burst and is not extended
+	// by later ones, so a busy cluster is still pushed every debounce window.
+	timer := time.NewTimer(opts.debounce)
+	timer.Stop()
+	pending := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-changes:
+			if !pending {
+				pending = true
+				timer.Reset(opts.debounce)
+			}
+		case <-timer.C:
+			pending = false
+			sync(false)
+		case <-resync:
+			log.Info("Running full resync")
+			if cluster, err = s.fetchCluster(ctx); err != nil {
+				log.Error("Sync failed, waiting for changes", "error", err)
+				continue
+			}
+			sync(true)
+		}
+	}
+}
+
+// snapshot converts the objects currently held by the informer caches that
+// pass the filter.
+func (s *syncConfig) snapshot(sources []watchSource) ([]api.ResourceProviderResource, error) {
+	resources := make([]api.ResourceProviderResource, 0)
+	for _, source := range sources {
+		for _, obj := range source.informer.GetStore().List() {
+			if object, err := meta.Accessor(obj); err == nil && !s.filter.Includes(object) {
+				continue
+			}
+			resource, ok, err := source.convert(obj)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", source.name, err)
+			}
+			if ok {
+				resources = append(resources, resource)
+			}
+		}
+	}
+	return resources, nil
+}
+
+// scopedName names a source for logs, e.g. "deployments in prod".
+func scopedName(name, namespace string) string {
+	if namespace == metav1.NamespaceAll {
+		return name
+	}
+	return name + " in " + namespace
+}
+
+// stripManagedFields drops managed fields before objects enter the cache;
+// they are never synced and make up much of the memory of large clusters.
+func stripManagedFields(obj any) (any, error) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+	return obj, nil
+}
@@ -0,0 +1,104 @@
+package kubernetes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestWatch_PushesChanges(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	client, err := api.NewAPIKeyClientWithResponses(server.URL, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod", UID: "ns-prod"},
+	})
+	// The fake clientset drops events sent before a watch is open, so the
+	// test waits for the deployment watch before creating one.
+	watching := make(chan struct{})
+	var once sync.Once
+	clientset.PrependWatchReactor("deployments", func(clienttesting.Action) (bool, watch.Interface, error) {
+		once.Do(func() { close(watching) })
+		return false, nil, nil
+	})
+
+	pushes := make(chan []api.ResourceProviderResource, 10)
+	push := func(_ context.Context, resources []api.ResourceProviderResource, _ bool) error {
+		pushes <- resources
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	s := newSync("cluster", "workspace", client, nil, "cluster-a")
+	selectors := ResourceTypes{ResourceNamespace, ResourceDeployment}
+	go func() {
+		done <- s.watch(ctx, clientset, nil, selectors, nil, nil, watchOptions{debounce: 10 * time.Millisecond}, push)
+	}()
+
+	next := func() []api.ResourceProviderResource {
+		t.Helper()
+		select {
+		case resources := <-pushes:
+			return resources
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a push")
+			return nil
+		}
+	}
+
+	if initial := next(); len(initial) != 1 || initial[0].Identifier != "ns-prod" {
+		t.Fatalf("unexpected initial push %+v", initial)
+	}
+
+	<-watching
+	deployments := clientset.AppsV1().Deployments("prod")
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web", UID: "deploy-web"}}
+	if _, err := deployments.Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if added := next(); len(added) != 2 {
+		t.Fatalf("expected the deployment to be added, got %+v", added)
+	}
+
+	if err := deployments.Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if removed := next(); len(removed) != 1 {
+		t.Fatalf("expected the deployment to be removed, got %+v", removed)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStripManagedFields(t *testing.T) {
+	var obj runtime.Object = &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubelet"}},
+	}}
+	stripped, err := stripManagedFields(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := stripped.(*corev1.Node).ManagedFields; fields != nil {
+		t.Errorf("expected managed fields to be dropped, got %+v", fields)
+	}
+}
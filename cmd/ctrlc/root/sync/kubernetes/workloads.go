@@ -0,0 +1,84 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/helm"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/rest"
+)
+
+// WorkloadOptions are the --with-workloads flags of the cloud cluster syncs,
+// which sync what runs inside every discovered cluster after the clusters
+// themselves.
+type WorkloadOptions struct {
+	Enabled     bool
+	Helm        bool
+	Selectors   ResourceTypes
+	Concurrency int
+}
+
+func (o *WorkloadOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.Enabled, "with-workloads", false, "Also sync the namespaces, deployments and nodes inside every discovered cluster")
+	flags.BoolVar(&o.Helm, "with-helm", false, "With --with-workloads, also sync the Helm releases of every cluster")
+	flags.Var(&o.Selectors, "workload-selector", "With --with-workloads, select resources to sync [nodes|deployments|namespaces] (repeatable; default is all)")
+	flags.IntVar(&o.Concurrency, "workload-concurrency", 8, "Maximum number of clusters whose workloads are synced at the same time")
+}
+
+// Cluster is a cluster found by a cloud sync and the client configuration
+// built from the cloud credentials to reach it.
+type Cluster struct {
+	// Identifier is the identifier of the synced cluster resource.
+	Identifier string
+	Name       string
+	// Provider names the providers of the cluster's workloads: Provider for
+	// the Kubernetes objects and Provider-helm for the Helm releases. It
+	// must be unique per cluster.
+	Provider string
+	Config   *rest.Config
+	// Metadata is set on every synced Kubernetes object and Helm release,
+	// over what they inherit from the cluster resource.
+	Metadata map[string]string
+}
+
+// SyncWorkloads runs the Kubernetes sync, and with --with-helm the Helm sync,
+// in every cluster with its identifier pre-set, so the objects inherit the
+// cluster's metadata. Clusters are synced concurrently and a failing cluster
+// does not stop the others. It must run after the clusters were upserted.
+func SyncWorkloads(ctx context.Context, opts WorkloadOptions, clusters []Cluster) error {
+	if !opts.Enabled || len(clusters) == 0 {
+		return nil
+	}
+	log.Info("Syncing cluster workloads", "clusters", len(clusters))
+
+	byProvider := make(map[string]Cluster, len(clusters))
+	contexts := make([]kubeconfig.Context, 0, len(clusters))
+	for _, cluster := range clusters {
+		byProvider[cluster.Provider] = cluster
+		contexts = append(contexts, kubeconfig.Context{Name: cluster.Provider, Config: cluster.Config})
+	}
+	return kubeconfig.ForEach(ctx, contexts, opts.Concurrency, func(ctx context.Context, c kubeconfig.Context) error {
+		cluster := byProvider[c.Name]
+		o := options{selectors: opts.Selectors, metadata: cluster.Metadata}
+		if err := o.runCluster(ctx, false, cluster.Config, nil, cluster.Identifier, cluster.Name, cluster.Provider); err != nil {
+			return err
+		}
+		if !opts.Helm {
+			return nil
+		}
+		return helm.SyncConfig(ctx, helmOptions(cluster), cluster.Config)
+	})
+}
+
+// helmOptions returns the options of a cluster's Helm sync. The releases
+// carry the same metadata as the cluster's Kubernetes objects.
+func helmOptions(cluster Cluster) helm.Options {
+	return helm.Options{
+		Provider:          cluster.Provider + "-helm",
+		ClusterIdentifier: cluster.Identifier,
+		ClusterName:       cluster.Name,
+		Metadata:          cluster.Metadata,
+	}
+}
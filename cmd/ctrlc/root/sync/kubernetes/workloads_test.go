@@ -0,0 +1,109 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api/apitest"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// newNamespaceServer serves a Kubernetes API with the given namespaces.
+func newNamespaceServer(t *testing.T, names ...string) *httptest.Server {
+	t.Helper()
+	list := corev1.NamespaceList{TypeMeta: metav1.TypeMeta{Kind: "NamespaceList", APIVersion: "v1"}}
+	for _, name := range names {
+		list.Items = append(list.Items, corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID("uid-" + name)}})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSyncWorkloads(t *testing.T) {
+	api := apitest.NewServer(t)
+	t.Cleanup(viper.Reset)
+	viper.Set("url", api.URL)
+	viper.Set("api-key", "test")
+	viper.Set("workspace", apitest.WorkspaceID)
+	viper.Set("state-dir", t.TempDir())
+
+	prod := newNamespaceServer(t, "default", "payments")
+	clusters := []Cluster{
+		{
+			Identifier: "prod-id",
+			Name:       "prod",
+			Provider:   "prod-workloads",
+			Config:     &rest.Config{Host: prod.URL},
+			Metadata:   map[string]string{"vcluster/name": "tenant"},
+		},
+		{
+			Identifier: "down-id",
+			Name:       "down",
+			Provider:   "down-workloads",
+			Config:     &rest.Config{Host: "http://127.0.0.1:1"},
+		},
+	}
+	opts := WorkloadOptions{Enabled: true, Selectors: ResourceTypes{ResourceNamespace}, Concurrency: 2}
+
+	err := SyncWorkloads(context.Background(), opts, clusters)
+	if err == nil || !strings.Contains(err.Error(), "context down-workloads") {
+		t.Fatalf("error = %v, want the unreachable cluster to fail", err)
+	}
+
+	// The unreachable cluster does not stop the others.
+	sets := api.Sets[apitest.ProviderID("prod-workloads")]
+	if len(sets) != 1 || len(sets[0]) != 2 {
+		t.Fatalf("set requests = %v, want one with both namespaces", sets)
+	}
+	for _, resource := range sets[0] {
+		if resource.Metadata["vcluster/name"] != "tenant" {
+			t.Errorf("%s: expected the cluster metadata, got %v", resource.Name, resource.Metadata)
+		}
+		if !strings.HasPrefix(resource.Name, "prod/") {
+			t.Errorf("expected %s to be named after its cluster", resource.Name)
+		}
+	}
+	if _, ok := api.Sets[apitest.ProviderID("down-workloads")]; ok {
+		t.Error("expected nothing to be synced for the unreachable cluster")
+	}
+}
+
+func TestSyncWorkloads_Disabled(t *testing.T) {
+	// Without --with-workloads no cluster is contacted.
+	clusters := []Cluster{{Provider: "unreachable", Config: &rest.Config{Host: "http://127.0.0.1:1"}}}
+	if err := SyncWorkloads(context.Background(), WorkloadOptions{}, clusters); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHelmOptions_CarryClusterMetadata(t *testing.T) {
+	cluster := Cluster{
+		Identifier: "tenant-id",
+		Name:       "tenant",
+		Provider:   "vcluster-tenant",
+		Metadata:   map[string]string{"vcluster/name": "tenant"},
+	}
+	opts := helmOptions(cluster)
+	if opts.Provider != "vcluster-tenant-helm" || opts.ClusterIdentifier != "tenant-id" || opts.ClusterName != "tenant" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+	if opts.Metadata["vcluster/name"] != "tenant" {
+		t.Errorf("expected the releases to carry the cluster metadata, got %v", opts.Metadata)
+	}
+}
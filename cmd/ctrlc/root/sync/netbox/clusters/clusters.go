@@ -28,7 +28,7 @@ func NewSyncClustersCmd() *cobra.Command {
 			$ ctrlc sync netbox clusters --netbox-url https://netbox.example.com --netbox-token $NETBOX_TOKEN
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			log.Info("Syncing Netbox clusters into Ctrlplane")
 
 			client := netbox.NewAPIClientFor(netboxURL, netboxToken)
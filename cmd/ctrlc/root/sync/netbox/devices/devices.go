@@ -1,7 +1,6 @@
 package devices
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -32,7 +31,7 @@ func NewSyncDevicesCmd() *cobra.Command {
 			$ ctrlc sync netbox devices --netbox-url https://netbox.example.com --netbox-token $NETBOX_TOKEN
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			log.Info("Syncing Netbox devices into Ctrlplane")
 
 			filters := deviceFilters{
@@ -70,7 +69,7 @@ func NewSyncDevicesCmd() *cobra.Command {
 				fmt.Println(string(b))
 			}
 
-			return common.UpsertResources(ctx, resources, &providerName)
+			return common.UpsertResources(ctx, resources, &providerName, common.WithRelationshipRules(relationshipRules...))
 		},
 	}
 
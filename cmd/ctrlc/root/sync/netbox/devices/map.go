@@ -100,3 +100,15 @@ func mapDevice(device netboxDevice) api.ResourceProviderResource {
 		Metadata:   metadata,
 	}
 }
+
+// relationshipRules links devices to the site they are located in, as synced
+// by `ctrlc sync netbox sites`.
+var relationshipRules = []api.UpsertRelationshipRuleRequest{
+	{
+		Reference: "site",
+		Name:      "Netbox Device Site",
+		Cel: `from.version == "netbox/device/v1" &&
+			to.version == "netbox/site/v1" &&
+			from.metadata["netbox/site-id"] == to.metadata["netbox/id"]`,
+	},
+}
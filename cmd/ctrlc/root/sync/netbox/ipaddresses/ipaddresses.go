@@ -28,7 +28,7 @@ func NewSyncIPAddressesCmd() *cobra.Command {
 			$ ctrlc sync netbox ip-addresses --netbox-url https://netbox.example.com --netbox-token $NETBOX_TOKEN
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			log.Info("Syncing Netbox IP addresses into Ctrlplane")
 
 			client := netbox.NewAPIClientFor(netboxURL, netboxToken)
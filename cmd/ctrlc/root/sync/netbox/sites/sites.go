@@ -28,7 +28,7 @@ func NewSyncSitesCmd() *cobra.Command {
 			$ ctrlc sync netbox sites --netbox-url https://netbox.example.com --netbox-token $NETBOX_TOKEN
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			log.Info("Syncing Netbox sites into Ctrlplane")
 
 			client := netbox.NewAPIClientFor(netboxURL, netboxToken)
@@ -28,7 +28,7 @@ func NewSyncVMsCmd() *cobra.Command {
 			$ ctrlc sync netbox vms --netbox-url https://netbox.example.com --netbox-token $NETBOX_TOKEN
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			ctx := cmd.Context()
 			log.Info("Syncing Netbox virtual machines into Ctrlplane")
 
 			client := netbox.NewAPIClientFor(netboxURL, netboxToken)
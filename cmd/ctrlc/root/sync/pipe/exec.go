@@ -0,0 +1,213 @@
+package pipe
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/charmbracelet/log"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/spf13/cobra"
+)
+
+// stderrTailLines is how many trailing stderr lines are included in the error
+// of a failed discovery command.
+const stderrTailLines = 20
+
+// NewSyncExecCmd runs a discovery command and syncs the resources it prints.
+// Unlike pipe it is scheduled with --interval, so a failed run must never
+// publish a partial result.
+func NewSyncExecCmd() *cobra.Command {
+	var providerName string
+	var format string
+	var timeout time.Duration
+	var env []string
+	var csvColumns map[string]string
+	var csvDefaults map[string]string
+
+	cmd := &cobra.Command{
+		Use:   "exec --provider <name> -- <command> [args...]",
+		Short: "Run a discovery command and sync the resources it prints",
+		Long: heredoc.Doc(`
+			Runs the given command and parses its stdout with the same parser as
+			"ctrlc sync pipe". The resources are only published when the command
+			exits successfully, so a crashing script never wipes the provider.
+			Stderr is forwarded to the log.
+		`),
+		Example: heredoc.Doc(`
+			# Run a discovery script every 10 minutes
+			$ ctrlc sync exec --provider custom-db --interval 10m -- ./discover.sh
+
+			# NDJSON output, a timeout and extra environment for the script
+			$ ctrlc sync exec --provider lab --format ndjson --timeout 2m \
+			    --env REGION=us-east-1 -- python3 discover.py --all
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "" {
+				format = formatJSON
+			}
+			ctx := cmd.Context()
+
+			set, err := runDiscovery(ctx, args, discoveryOptions{
+				format:   format,
+				timeout:  timeout,
+				env:      append(env, "CTRLC_PROVIDER="+providerName),
+				csv:      csvMapping{columns: csvColumns, defaults: csvDefaults},
+				provider: providerName,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := set.err(); err != nil {
+				return err
+			}
+
+			log.Info("Syncing resources from command", "count", len(set.resources), "provider", providerName)
+			return ctrlp.UpsertResources(ctx, set.resources, &providerName, ctrlp.WithVariables(set.variables))
+		},
+	}
+
+	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Resource provider name")
+	cmd.Flags().StringVar(&format, "format", formatJSON, "Format of the command output: json, ndjson, yaml or csv")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Kill the command if it runs longer than this (0 = no timeout)")
+	cmd.Flags().StringArrayVarP(&env, "env", "e", nil, "Set an environment variable for the command, KEY=VALUE (repeatable)")
+	cmd.Flags().StringToStringVar(&csvColumns, "csv-column", nil, "Map a resource field to a CSV column, e.g. identifier=id (repeatable)")
+	cmd.Flags().StringToStringVar(&csvDefaults, "csv-default", nil, "Value for a resource field missing from the CSV, e.g. version=custom/v1 (repeatable)")
+	cmd.MarkFlagRequired("provider")
+
+	return cmd
+}
+
+type discoveryOptions struct {
+	format   string
+	timeout  time.Duration
+	env      []string
+	csv      csvMapping
+	provider string
+}
+
+// runDiscovery runs the command and parses its stdout while it runs. Any
+// failure, including a non-zero exit after valid output, returns an error so
+// that nothing is published.
+func runDiscovery(ctx context.Context, args []string, opts discoveryOptions) (*resourceSet, error) {
+	var set *resourceSet
+	err := runCommand(ctx, args, opts, func(stdout io.Reader) error {
+		var err error
+		set, err = readResources(stdout, opts.format, opts.csv)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if set.count == 0 {
+		return nil, fmt.Errorf("discovery command printed no resources, not publishing")
+	}
+	return set, nil
+}
+
+// runCommand runs the command and hands its stdout to parse while it runs.
+// It fails if the command fails, times out or its output does not parse.
+func runCommand(ctx context.Context, args []string, opts discoveryOptions, parse func(io.Reader) error) error {
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	child := exec.CommandContext(ctx, args[0], args[1:]...)
+	child.Env = append(os.Environ(), opts.env...)
+	child.Cancel = func() error {
+		return child.Process.Signal(syscall.SIGTERM)
+	}
+	child.WaitDelay = 10 * time.Second
+
+	// Output goes through io.Pipes rather than StdoutPipe/StderrPipe so that
+	// Wait, bounded by WaitDelay, also ends our reads when the command is
+	// killed but a grandchild still holds its output open.
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+	child.Stdout = stdoutWriter
+	child.Stderr = stderrWriter
+
+	log.Debug("Running discovery command", "command", args, "provider", opts.provider)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start discovery command: %w", err)
+	}
+
+	tail := &stderrTail{}
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		tail.consume(stderr, args[0])
+	}()
+
+	waitDone := make(chan error, 1)
+	go func() {
+		err := child.Wait()
+		stdoutWriter.Close()
+		stderrWriter.Close()
+		waitDone <- err
+	}()
+
+	parseErr := parse(stdout)
+	// Drain unread output so the command is not blocked writing to a full pipe.
+	io.Copy(io.Discard, stdout)
+	waitErr := <-waitDone
+	<-stderrDone
+
+	if waitErr != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("discovery command timed out after %s, not publishing%s", opts.timeout, tail)
+		}
+		return fmt.Errorf("discovery command failed, not publishing: %w%s", waitErr, tail)
+	}
+	if parseErr != nil {
+		return fmt.Errorf("failed to parse discovery command output: %w", parseErr)
+	}
+	return nil
+}
+
+// stderrTail logs each stderr line of the command and keeps the last few for
+// error messages.
+type stderrTail struct {
+	lines []string
+}
+
+func (t *stderrTail) consume(r io.Reader, command string) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		log.Info("Discovery command output", "command", command, "stderr", line)
+		t.add(line)
+	}
+	if err := scanner.Err(); err != nil {
+		// Keep draining so the command is not blocked writing to a full pipe.
+		t.add(fmt.Sprintf("(stderr no longer read: %v)", err))
+		io.Copy(io.Discard, r)
+	}
+}
+
+func (t *stderrTail) add(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > stderrTailLines {
+		t.lines = t.lines[1:]
+	}
+}
+
+// String formats the captured lines as a suffix for an error message.
+func (t *stderrTail) String() string {
+	if len(t.lines) == 0 {
+		return ""
+	}
+	return "\nstderr:\n  " + strings.Join(t.lines, "\n  ")
+}
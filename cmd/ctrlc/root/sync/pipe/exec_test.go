@@ -0,0 +1,92 @@
+package pipe
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const discoveredResource = `{"name":"web-1","identifier":"web-1","version":"custom/v1","kind":"Server"}`
+
+func TestRunDiscovery_PublishesOnSuccess(t *testing.T) {
+	set, err := runDiscovery(context.Background(),
+		[]string{"sh", "-c", `echo "$DISCOVERED"; echo progress >&2`},
+		discoveryOptions{format: formatNDJSON, env: []string{"DISCOVERED=" + discoveredResource}},
+	)
+	if err != nil {
+		t.Fatalf("runDiscovery returned error: %v", err)
+	}
+	if len(set.resources) != 1 || set.resources[0].Identifier != "web-1" {
+		t.Fatalf("unexpected resources: %+v", set.resources)
+	}
+}
+
+func TestRunDiscovery_NonZeroExitDoesNotPublish(t *testing.T) {
+	set, err := runDiscovery(context.Background(),
+		[]string{"sh", "-c", `echo '` + discoveredResource + `'; echo boom >&2; exit 3`},
+		discoveryOptions{format: formatNDJSON},
+	)
+	if err == nil {
+		t.Fatalf("expected an error, got resources %+v", set.resources)
+	}
+	if !strings.Contains(err.Error(), "not publishing") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected error with stderr tail, got %v", err)
+	}
+}
+
+func TestRunDiscovery_Timeout(t *testing.T) {
+	_, err := runDiscovery(context.Background(),
+		[]string{"sh", "-c", "exec sleep 5"},
+		discoveryOptions{format: formatJSON, timeout: 100 * time.Millisecond},
+	)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestPluginOutput_MergesVariables(t *testing.T) {
+	var output pluginOutput
+	err := runCommand(context.Background(),
+		[]string{"sh", "-c", `echo '{"version":"v1","resources":[{"name":"web-1","identifier":"web-1","version":"custom/v1","kind":"Server","variables":{"a":1}}],"variables":{"web-1":{"b":"x"}},"relationships":[{"name":"r","reference":"host","cel":"true"}]}'`},
+		discoveryOptions{},
+		func(stdout io.Reader) error { return json.NewDecoder(stdout).Decode(&output) },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources, variables, err := output.parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || len(output.Relationships) != 1 {
+		t.Fatalf("unexpected output: %+v", output)
+	}
+	if vars := variables["web-1"]; vars["a"] != float64(1) || vars["b"] != "x" {
+		t.Fatalf("expected inline and top-level variables to merge, got %#v", variables)
+	}
+
+	output.Version = "v2"
+	if _, _, err := output.parse(); err == nil {
+		t.Fatal("expected an unsupported protocol version to fail")
+	}
+}
+
+func TestRunDiscovery_LongStderrLine(t *testing.T) {
+	// A stderr line over maxLineSize followed by more output must not block
+	// the command.
+	script := `head -c 17000000 /dev/zero | tr '\0' x >&2; echo >&2; echo after >&2; echo "$DISCOVERED"`
+	set, err := runDiscovery(context.Background(),
+		[]string{"sh", "-c", script},
+		discoveryOptions{format: formatNDJSON, timeout: 10 * time.Second, env: []string{"DISCOVERED=" + discoveredResource}},
+	)
+	if err != nil {
+		t.Fatalf("runDiscovery returned error: %v", err)
+	}
+	if len(set.resources) != 1 {
+		t.Fatalf("unexpected resources: %+v", set.resources)
+	}
+}
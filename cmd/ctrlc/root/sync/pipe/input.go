@@ -0,0 +1,267 @@
+package pipe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatYAML   = "yaml"
+	formatCSV    = "csv"
+)
+
+// maxLineSize bounds the memory used for a single NDJSON line.
+const maxLineSize = 16 * 1024 * 1024
+
+// csvMapping describes how CSV columns map onto resource fields.
+type csvMapping struct {
+	// columns maps a resource field (name, identifier, version, kind) to the
+	// header of the column holding it. Unmapped fields use the column with
+	// the same name as the field.
+	columns map[string]string
+	// defaults supplies constant values for fields without a column, e.g.
+	// version=custom/v1.
+	defaults map[string]string
+}
+
+// formatFromPath guesses the input format from a file extension, falling back
+// to JSON.
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return formatNDJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".csv":
+		return formatCSV
+	default:
+		return formatJSON
+	}
+}
+
+// readResources decodes resources from r in the given format and adds them
+// to a resource set.
+func readResources(r io.Reader, format string, mapping csvMapping) (*resourceSet, error) {
+	set := &resourceSet{}
+	var err error
+	switch format {
+	case formatJSON:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			return nil, fmt.Errorf("input is empty -- expected JSON resource array")
+		}
+		resources, err := parseResources(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			set.add(resource)
+		}
+	case formatNDJSON:
+		err = readNDJSON(r, set)
+	case formatYAML:
+		err = readYAML(r, set)
+	case formatCSV:
+		err = readCSV(r, mapping, set)
+	default:
+		return nil, fmt.Errorf("unsupported format %q (expected json, ndjson, yaml or csv)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// readNDJSON decodes one resource per line and adds it to the set before
+// reading the next, so only the current line, at most maxLineSize, and the
+// outgoing resources are held in memory. Blank lines are skipped.
+func readNDJSON(r io.Reader, set *resourceSet) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var resource resourceInput
+		if err := json.Unmarshal(text, &resource); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		resource.line = line
+		set.add(resource)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("line %d: %w", line+1, err)
+	}
+	return nil
+}
+
+// readYAML decodes a stream of YAML documents. Each document is either a
+// single resource or a list of resources.
+func readYAML(r io.Reader, set *resourceSet) error {
+	decoder := yaml.NewDecoder(r)
+
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid YAML input: %w", err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		root := doc.Content[0]
+		items := []*yaml.Node{root}
+		if root.Kind == yaml.SequenceNode {
+			items = root.Content
+		}
+
+		for _, item := range items {
+			resource, err := decodeYAMLResource(item)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", item.Line, err)
+			}
+			set.add(resource)
+		}
+	}
+	return nil
+}
+
+// decodeYAMLResource converts a YAML node to JSON so that it goes through the
+// same decoding, including variables handling, as JSON input.
+func decodeYAMLResource(node *yaml.Node) (resourceInput, error) {
+	var resource resourceInput
+	if node.Kind != yaml.MappingNode {
+		return resource, fmt.Errorf("expected a resource mapping")
+	}
+
+	var value map[string]any
+	if err := node.Decode(&value); err != nil {
+		return resource, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return resource, err
+	}
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return resource, err
+	}
+	resource.line = node.Line
+	return resource, nil
+}
+
+// readCSV decodes a CSV document with a header row. Columns mapped to
+// name/identifier/version/kind set those fields; columns named "config.<key>"
+// or "variables.<key>" set config and variables; all other columns become
+// metadata. Empty cells are skipped.
+func readCSV(r io.Reader, mapping csvMapping, set *resourceSet) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("input is empty -- expected CSV header row")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	fieldColumns := map[string]int{}
+	for _, field := range []string{"name", "identifier", "version", "kind"} {
+		column := field
+		if mapped, ok := mapping.columns[field]; ok {
+			column = mapped
+		}
+		for i, h := range header {
+			if h == column {
+				fieldColumns[field] = i
+			}
+		}
+		if _, ok := fieldColumns[field]; !ok {
+			if _, mapped := mapping.columns[field]; mapped {
+				return fmt.Errorf("column %q mapped to %s not found in CSV header", column, field)
+			}
+		}
+	}
+	for field := range mapping.columns {
+		if _, ok := fieldColumns[field]; !ok {
+			return fmt.Errorf("unknown resource field %q in CSV column mapping", field)
+		}
+	}
+
+	isField := map[int]bool{}
+	for _, i := range fieldColumns {
+		isField[i] = true
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV input: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		resource := resourceInput{
+			Config:   map[string]any{},
+			Metadata: map[string]string{},
+			line:     line,
+		}
+		field := func(name string) string {
+			if i, ok := fieldColumns[name]; ok && record[i] != "" {
+				return record[i]
+			}
+			return mapping.defaults[name]
+		}
+		resource.Name = field("name")
+		resource.Identifier = field("identifier")
+		resource.Version = field("version")
+		resource.Kind = field("kind")
+
+		for i, value := range record {
+			if isField[i] || value == "" {
+				continue
+			}
+			column := header[i]
+			switch {
+			case strings.HasPrefix(column, "config."):
+				resource.Config[strings.TrimPrefix(column, "config.")] = value
+			case strings.HasPrefix(column, "variables."):
+				if resource.Variables == nil {
+					resource.Variables = map[string]any{}
+				}
+				resource.Variables[strings.TrimPrefix(column, "variables.")] = value
+				resource.hasVariables = true
+			default:
+				resource.Metadata[strings.TrimPrefix(column, "metadata.")] = value
+			}
+		}
+
+		set.add(resource)
+	}
+	return nil
+}
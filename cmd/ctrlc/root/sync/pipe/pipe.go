@@ -1,16 +1,15 @@
 package pipe
 
 import (
-	"context"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/MakeNowJust/heredoc/v2"
-	"github.com/avast/retry-go"
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
 	"github.com/ctrlplanedev/cli/internal/cliutil"
@@ -21,10 +20,14 @@ import (
 
 func NewSyncPipeCmd() *cobra.Command {
 	var providerName string
+	var format string
+	var fromFile string
+	var csvColumns map[string]string
+	var csvDefaults map[string]string
 
 	cmd := &cobra.Command{
 		Use:   "pipe",
-		Short: "Sync resources from stdin into Ctrlplane",
+		Short: "Sync resources from stdin or a file into Ctrlplane",
 		Example: heredoc.Doc(`
 			# One-shot sync from a script
 			$ ./discover-databases.sh | ctrlc sync pipe --provider "custom-db"
@@ -41,43 +44,54 @@ func NewSyncPipeCmd() *cobra.Command {
 			$ echo '[{"name":"web-1","identifier":"web-1-prod","version":"custom/v1","kind":"Server","variables":{"env":"prod","tier":"frontend"}}]' \
 			    | ctrlc sync pipe --provider "my-servers"
 
+			# Newline-delimited JSON, decoded line by line
+			$ ./discover.sh --ndjson | ctrlc sync pipe --provider "custom-db" --format ndjson
+
+			# Multi-document YAML from a file
+			$ ctrlc sync pipe --provider "lab" --from-file inventory.yaml
+
+			# CSV with column mapping; other columns become metadata, config.* and variables.* columns set config and variables
+			$ ctrlc sync pipe --provider "cmdb" --from-file hosts.csv \
+			    --csv-column identifier=serial --csv-column name=hostname \
+			    --csv-default version=cmdb/v1 --csv-default kind=Server
+
 			# From curl with jq transformation
 			$ curl -s https://cmdb.internal/api/servers \
 			    | jq '[.[] | {name, identifier: .id, version: "cmdb/v1", kind: "Server", config: ., metadata: {}}]' \
 			    | ctrlc sync pipe --provider "cmdb"
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Detect piped stdin
-			stat, err := os.Stdin.Stat()
-			if err != nil {
-				return fmt.Errorf("failed to stat stdin: %w", err)
-			}
-			if (stat.Mode() & os.ModeCharDevice) != 0 {
-				return fmt.Errorf("no piped input detected -- pipe JSON resources to this command")
+			if format == "" {
+				format = formatJSON
+				if fromFile != "" {
+					format = formatFromPath(fromFile)
+				}
 			}
 
-			// Read all stdin
-			data, err := io.ReadAll(os.Stdin)
+			input, closeInput, err := openInput(fromFile)
 			if err != nil {
-				return fmt.Errorf("failed to read stdin: %w", err)
-			}
-			if len(data) == 0 {
-				return fmt.Errorf("stdin is empty -- expected JSON resource array")
+				return err
 			}
+			defer closeInput()
 
-			// Parse JSON -- try array first, then single object
-			resourceInputs, err := parseResources(data)
+			set, err := readResources(input, format, csvMapping{
+				columns:  csvColumns,
+				defaults: csvDefaults,
+			})
 			if err != nil {
 				return err
 			}
+			if set.count == 0 {
+				return fmt.Errorf("no resources found in input")
+			}
 
 			// Validate required fields
-			if err := validateResources(resourceInputs); err != nil {
+			if err := set.err(); err != nil {
 				return err
 			}
 
-			resources := toAPIResources(resourceInputs)
-			log.Info("Syncing resources from stdin", "count", len(resources), "provider", providerName)
+			resources := set.resources
+			log.Info("Syncing resources from input", "count", len(resources), "provider", providerName, "format", format)
 
 			// Create API client
 			apiURL := viper.GetString("url")
@@ -95,15 +109,16 @@ func NewSyncPipeCmd() *cobra.Command {
 			}
 
 			// Upsert resources
-			ctx := context.Background()
+			ctx := cmd.Context()
 			upsertResp, err := rp.UpsertResource(ctx, resources)
 			if err != nil {
 				return fmt.Errorf("failed to upsert resources: %w", err)
 			}
 
-			workspaceID := ctrlplaneClient.GetWorkspaceID(ctx, workspace).String()
-			if err := syncResourceVariables(ctx, ctrlplaneClient, workspaceID, resourceInputs); err != nil {
-				return err
+			if len(set.variables) > 0 {
+				if err := rp.UpdateVariables(ctx, set.variables); err != nil {
+					return err
+				}
 			}
 
 			log.Info("Response from upserting resources", "status", upsertResp.Status)
@@ -113,6 +128,10 @@ func NewSyncPipeCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Resource provider name")
+	cmd.Flags().StringVar(&format, "format", "", "Input format: json, ndjson, yaml or csv (default: from the --from-file extension, else json)")
+	cmd.Flags().StringVarP(&fromFile, "from-file", "f", "", "Read resources from this file instead of stdin")
+	cmd.Flags().StringToStringVar(&csvColumns, "csv-column", nil, "Map a resource field to a CSV column, e.g. identifier=id (repeatable)")
+	cmd.Flags().StringToStringVar(&csvDefaults, "csv-default", nil, "Value for a resource field missing from the CSV, e.g. version=custom/v1 (repeatable)")
 	cmd.MarkFlagRequired("provider")
 
 	return cmd
@@ -128,6 +147,8 @@ type resourceInput struct {
 	Variables  map[string]any    `json:"-"`
 
 	hasVariables bool `json:"-"`
+	// line is the input line the resource started on, when known.
+	line int
 }
 
 func (r *resourceInput) UnmarshalJSON(data []byte) error {
@@ -193,6 +214,12 @@ func parseResources(data []byte) ([]resourceInput, error) {
 		return []resourceInput{single}, nil
 	}
 
+	var syntaxErr *json.SyntaxError
+	if err := json.Unmarshal(data, &resources); errors.As(err, &syntaxErr) {
+		line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+		return nil, fmt.Errorf("invalid JSON input at line %d: %w", line, err)
+	}
+
 	// Show a snippet of the input for debugging
 	snippet := string(data)
 	if len(snippet) > 200 {
@@ -201,98 +228,102 @@ func parseResources(data []byte) ([]resourceInput, error) {
 	return nil, fmt.Errorf("invalid JSON input: %s", snippet)
 }
 
-func toAPIResources(resources []resourceInput) []api.ResourceProviderResource {
-	out := make([]api.ResourceProviderResource, 0, len(resources))
-	for _, r := range resources {
-		out = append(out, api.ResourceProviderResource{
-			Name:       r.Name,
-			Identifier: r.Identifier,
-			Version:    r.Version,
-			Kind:       r.Kind,
-			Config:     r.Config,
-			Metadata:   r.Metadata,
-		})
+// openInput returns the file to read resources from, or stdin when path is
+// empty or "-". Reading from an interactive terminal is rejected.
+func openInput(path string) (io.Reader, func(), error) {
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+		return f, func() { f.Close() }, nil
 	}
-	return out
-}
 
-// validateResources checks that each resource has the required fields:
-// Name, Identifier, Version, Kind.
-func validateResources(resources []resourceInput) error {
-	var errs []string
-	for i, r := range resources {
-		var missing []string
-		if r.Name == "" {
-			missing = append(missing, "name")
-		}
-		if r.Identifier == "" {
-			missing = append(missing, "identifier")
-		}
-		if r.Version == "" {
-			missing = append(missing, "version")
-		}
-		if r.Kind == "" {
-			missing = append(missing, "kind")
-		}
-		if len(missing) > 0 {
-			errs = append(errs, fmt.Sprintf("resource[%d]: missing required field(s) '%s'", i, strings.Join(missing, "', '")))
-		}
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat stdin: %w", err)
 	}
-	if len(errs) > 0 {
-		return fmt.Errorf("validation failed:\n  %s", strings.Join(errs, "\n  "))
+	if (stat.Mode() & os.ModeCharDevice) != 0 {
+		return nil, nil, fmt.Errorf("no piped input detected -- pipe resources to this command or use --from-file")
 	}
-	return nil
+	return os.Stdin, func() {}, nil
+}
+
+// resourceSet is the validated set of resources read from an input. Inputs
+// are converted as they are added, so readers that stream their input keep
+// only the outgoing resources, not every decoded input.
+type resourceSet struct {
+	resources []api.ResourceProviderResource
+	// variables holds the variables of resources that declared them, keyed
+	// by identifier.
+	variables map[string]map[string]any
+	// count is the number of inputs added, valid or not.
+	count int
+	errs  []string
 }
 
-func syncResourceVariables(
-	ctx context.Context,
-	client *api.ClientWithResponses,
-	workspaceID string,
-	resources []resourceInput,
-) error {
-	for _, resource := range resources {
-		if !resource.hasVariables {
-			continue
+// add checks that the input has the required fields, name, identifier,
+// version and kind, and adds it to the set.
+func (s *resourceSet) add(r resourceInput) {
+	index := s.count
+	s.count++
+
+	var missing []string
+	if r.Name == "" {
+		missing = append(missing, "name")
+	}
+	if r.Identifier == "" {
+		missing = append(missing, "identifier")
+	}
+	if r.Version == "" {
+		missing = append(missing, "version")
+	}
+	if r.Kind == "" {
+		missing = append(missing, "kind")
+	}
+	if len(missing) > 0 {
+		location := fmt.Sprintf("resource[%d]", index)
+		if r.line > 0 {
+			location = fmt.Sprintf("line %d", r.line)
 		}
+		s.errs = append(s.errs, fmt.Sprintf("%s: missing required field(s) '%s'", location, strings.Join(missing, "', '")))
+		return
+	}
 
-		vars := resource.Variables
+	s.resources = append(s.resources, api.ResourceProviderResource{
+		Name:       r.Name,
+		Identifier: r.Identifier,
+		Version:    r.Version,
+		Kind:       r.Kind,
+		Config:     r.Config,
+		Metadata:   r.Metadata,
+	})
+	if r.hasVariables {
+		vars := r.Variables
 		if vars == nil {
 			vars = map[string]any{}
 		}
-
-		err := retry.Do(
-			func() error {
-				varsResp, err := client.RequestResourceVariablesUpdateWithResponse(
-					ctx,
-					workspaceID,
-					resource.Identifier,
-					api.RequestResourceVariablesUpdateJSONRequestBody(vars),
-				)
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("failed to update resource variables for '%s': %w", resource.Identifier, err))
-				}
-				if varsResp == nil {
-					return retry.Unrecoverable(fmt.Errorf("failed to update resource variables for '%s': empty response", resource.Identifier))
-				}
-				if varsResp.StatusCode() == 404 {
-					return fmt.Errorf("resource '%s' not found yet, retrying", resource.Identifier)
-				}
-				if varsResp.StatusCode() >= 400 {
-					return retry.Unrecoverable(
-						fmt.Errorf("failed to update resource variables for '%s': %s", resource.Identifier, string(varsResp.Body)),
-					)
-				}
-				return nil
-			},
-			retry.Attempts(10),
-			retry.Delay(100*time.Millisecond),
-			retry.MaxDelay(15*time.Second),
-			retry.DelayType(retry.BackOffDelay),
-		)
-		if err != nil {
-			return err
+		if s.variables == nil {
+			s.variables = map[string]map[string]any{}
 		}
+		s.variables[r.Identifier] = vars
 	}
+}
 
+// err reports the inputs that failed validation.
+func (s *resourceSet) err() error {
+	if len(s.errs) > 0 {
+		return fmt.Errorf("validation failed:\n  %s", strings.Join(s.errs, "\n  "))
+	}
 	return nil
 }
+
+// validateResources checks that each resource has the required fields:
+// Name, Identifier, Version, Kind.
+func validateResources(resources []resourceInput) error {
+	var set resourceSet
+	for _, r := range resources {
+		set.add(r)
+	}
+	return set.err()
+}
@@ -1,6 +1,9 @@
 package pipe
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParseResources_ArrayWithVariables(t *testing.T) {
 	input := []byte(`[
@@ -70,3 +73,97 @@ func TestValidateResources_UnchangedRequiredFields(t *testing.T) {
 		t.Fatalf("expected validation to fail")
 	}
 }
+
+func TestReadResources_NDJSONReportsLine(t *testing.T) {
+	input := `{"name":"web-1","identifier":"web-1","version":"custom/v1","kind":"Server"}
+
+{"name":"web-2",`
+
+	_, err := readResources(strings.NewReader(input), formatNDJSON, csvMapping{})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Fatalf("expected error on line 3, got %v", err)
+	}
+}
+
+func TestReadResources_MultiDocYAML(t *testing.T) {
+	input := `name: web-1
+identifier: web-1
+version: custom/v1
+kind: Server
+variables:
+  env: prod
+---
+- name: web-2
+  identifier: web-2
+  version: custom/v1
+- name: web-3
+  identifier: web-3
+  version: custom/v1
+  kind: Server
+`
+
+	set, err := readResources(strings.NewReader(input), formatYAML, csvMapping{})
+	if err != nil {
+		t.Fatalf("readResources returned error: %v", err)
+	}
+	if set.count != 3 || len(set.resources) != 2 {
+		t.Fatalf("expected 3 resources, 2 of them valid, got %d and %d", set.count, len(set.resources))
+	}
+	if set.variables["web-1"]["env"] != "prod" {
+		t.Fatalf("expected web-1 variables to be decoded, got %#v", set.variables)
+	}
+
+	err = set.err()
+	if err == nil || !strings.Contains(err.Error(), "line 8: missing required field(s) 'kind'") {
+		t.Fatalf("expected missing kind on line 8, got %v", err)
+	}
+}
+
+func TestReadResources_CSVMapping(t *testing.T) {
+	input := `serial,hostname,rack,config.ip,variables.tier
+abc123,web-1,r1,10.0.0.1,frontend
+def456,web-2,,10.0.0.2,
+`
+
+	set, err := readResources(strings.NewReader(input), formatCSV, csvMapping{
+		columns:  map[string]string{"identifier": "serial", "name": "hostname"},
+		defaults: map[string]string{"version": "cmdb/v1", "kind": "Server"},
+	})
+	if err != nil {
+		t.Fatalf("readResources returned error: %v", err)
+	}
+	if err := set.err(); err != nil {
+		t.Fatalf("validation returned error: %v", err)
+	}
+
+	first := set.resources[0]
+	if first.Identifier != "abc123" || first.Name != "web-1" || first.Version != "cmdb/v1" || first.Kind != "Server" {
+		t.Fatalf("unexpected fields: %+v", first)
+	}
+	if first.Metadata["rack"] != "r1" || first.Config["ip"] != "10.0.0.1" || set.variables["abc123"]["tier"] != "frontend" {
+		t.Fatalf("unexpected metadata/config/variables: %+v %#v", first, set.variables)
+	}
+
+	second := set.resources[1]
+	if _, ok := second.Metadata["rack"]; ok {
+		t.Fatalf("expected empty cells to be skipped: %+v", second)
+	}
+	if _, ok := set.variables["def456"]; ok {
+		t.Fatalf("expected no variables for def456: %#v", set.variables)
+	}
+}
+
+func TestReadResources_CSVReportsLine(t *testing.T) {
+	input := `identifier,name,version,kind
+abc123,web-1,cmdb/v1,Server
+def456,,cmdb/v1,Server
+`
+
+	set, err := readResources(strings.NewReader(input), formatCSV, csvMapping{})
+	if err != nil {
+		t.Fatalf("readResources returned error: %v", err)
+	}
+	if err := set.err(); err == nil || !strings.Contains(err.Error(), "line 3: missing required field(s) 'name'") {
+		t.Fatalf("expected missing name on line 3, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package pipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/ctrlplanedev/cli/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+// pluginProtocolVersion is the version of the document sync plugins print,
+// passed to them in CTRLC_PLUGIN_PROTOCOL.
+const pluginProtocolVersion = "v1"
+
+// pluginOutput is the JSON document a ctrlc-sync-<name> plugin prints on
+// stdout. Resources use the same fields as "ctrlc sync pipe", including
+// inline variables; top-level variables are keyed by identifier.
+type pluginOutput struct {
+	Version       string                              `json:"version"`
+	Resources     []resourceInput                     `json:"resources"`
+	Variables     map[string]map[string]any           `json:"variables"`
+	Relationships []api.UpsertRelationshipRuleRequest `json:"relationships"`
+}
+
+// NewSyncPluginCmd syncs the output of a ctrlc-sync-<name> plugin found on
+// PATH. It behaves like "ctrlc sync exec" with the plugin as the command.
+func NewSyncPluginCmd(name string, path string) *cobra.Command {
+	var providerName string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   name + " [-- plugin args...]",
+		Short: fmt.Sprintf("Sync resources from the %s plugin", name),
+		Long: heredoc.Docf(`
+			Runs the plugin %s and syncs the resources it prints. Arguments
+			after -- are passed to the plugin.
+
+			Plugins print one JSON document on stdout:
+
+			  {
+			    "version": "v1",
+			    "resources": [{"identifier": "...", "name": "...", "kind": "...", "version": "...",
+			                   "config": {}, "metadata": {}, "variables": {}}],
+			    "variables": {"<identifier>": {"key": "value"}},
+			    "relationships": [{"name": "...", "reference": "...", "cel": "..."}]
+			  }
+
+			The environment carries CTRLC_PROVIDER and CTRLC_PLUGIN_PROTOCOL, and
+			the resolved CTRLPLANE_URL, CTRLPLANE_API_KEY and CTRLPLANE_WORKSPACE.
+			Nothing is published unless the plugin exits successfully.
+		`, path),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if providerName == "" {
+				providerName = name
+			}
+			ctx := cmd.Context()
+
+			var output pluginOutput
+			err := runCommand(ctx, append([]string{path}, args...), discoveryOptions{
+				timeout:  timeout,
+				env:      append(plugin.Env(), "CTRLC_PROVIDER="+providerName, "CTRLC_PLUGIN_PROTOCOL="+pluginProtocolVersion),
+				provider: providerName,
+			}, func(stdout io.Reader) error {
+				return json.NewDecoder(stdout).Decode(&output)
+			})
+			if err != nil {
+				return err
+			}
+
+			resources, variables, err := output.parse()
+			if err != nil {
+				return fmt.Errorf("plugin %s: %w", name, err)
+			}
+
+			log.Info("Syncing resources from plugin", "plugin", name, "count", len(resources), "provider", providerName)
+			return ctrlp.UpsertResources(ctx, resources, &providerName,
+				ctrlp.WithVariables(variables),
+				ctrlp.WithRelationshipRules(output.Relationships...),
+			)
+		},
+	}
+
+	cmd.Flags().StringVarP(&providerName, "provider", "p", "", "Resource provider name (defaults to the plugin name)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Kill the plugin if it runs longer than this (0 = no timeout)")
+
+	return cmd
+}
+
+// parse validates the document and returns its resources and variables.
+func (o pluginOutput) parse() ([]api.ResourceProviderResource, map[string]map[string]any, error) {
+	if o.Version != "" && o.Version != pluginProtocolVersion {
+		return nil, nil, fmt.Errorf("unsupported protocol version %q (expected %s)", o.Version, pluginProtocolVersion)
+	}
+	if len(o.Resources) == 0 {
+		return nil, nil, fmt.Errorf("printed no resources, not publishing")
+	}
+	var set resourceSet
+	for _, resource := range o.Resources {
+		set.add(resource)
+	}
+	if err := set.err(); err != nil {
+		return nil, nil, err
+	}
+
+	variables := set.variables
+	if variables == nil {
+		variables = map[string]map[string]any{}
+	}
+	for identifier, vars := range o.Variables {
+		if variables[identifier] == nil {
+			variables[identifier] = map[string]any{}
+		}
+		for key, value := range vars {
+			variables[identifier][key] = value
+		}
+	}
+	for i, rule := range o.Relationships {
+		if rule.Name == "" || rule.Reference == "" || rule.Cel == "" {
+			return nil, nil, fmt.Errorf("relationship %d needs name, reference and cel", i)
+		}
+	}
+	return set.resources, variables, nil
+}
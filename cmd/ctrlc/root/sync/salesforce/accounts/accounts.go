@@ -66,7 +66,7 @@ func NewSalesforceAccountsCmd() *cobra.Command {
 
 			log.Info("Syncing Salesforce accounts into Ctrlplane", "domain", domain)
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			sf, err := common.InitSalesforceClient(domain, consumerKey, consumerSecret)
 			if err != nil {
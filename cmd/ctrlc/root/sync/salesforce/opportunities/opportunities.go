@@ -65,7 +65,7 @@ func NewSalesforceOpportunitiesCmd() *cobra.Command {
 
 			log.Info("Syncing Salesforce opportunities into Ctrlplane", "domain", domain)
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 
 			sf, err := common.InitSalesforceClient(domain, consumerKey, consumerSecret)
 			if err != nil {
@@ -0,0 +1,305 @@
+package sqlsource
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+// resourceFields are the resource fields a column can be mapped to.
+var resourceFields = []string{"name", "identifier", "kind", "version"}
+
+// queryName matches the "-- name: <name>" marker of a named query file.
+var queryName = regexp.MustCompile(`^--\s*name:\s*(\S+)\s*$`)
+
+// queryOptions controls how rows are read and mapped to resources.
+type queryOptions struct {
+	driver string
+	query  string
+
+	// columns maps a resource field to the column holding it. Unmapped
+	// fields use the column with the same name as the field.
+	columns map[string]string
+	// defaults supplies constant values for fields without a column.
+	defaults map[string]string
+	// metadataPrefix is prepended to the names of extra columns stored as
+	// metadata.
+	metadataPrefix string
+	// jsonColumns are decoded and stored in config. Columns the database
+	// reports as JSON are always treated this way.
+	jsonColumns map[string]bool
+
+	// limit caps the number of rows read (0 = no limit).
+	limit int
+	// pageKey enables keyset pagination on this column with pageSize rows
+	// per query.
+	pageKey  string
+	pageSize int
+}
+
+// loadNamedQuery returns the query called name from a file of queries
+// separated by "-- name: <name>" lines. Without a name the file must hold a
+// single query.
+func loadNamedQuery(path, name string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open query file: %w", err)
+	}
+	defer f.Close()
+
+	queries := map[string]*strings.Builder{}
+	var order []string
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := queryName.FindStringSubmatch(line); match != nil {
+			current = match[1]
+			continue
+		}
+		if _, ok := queries[current]; !ok {
+			queries[current] = &strings.Builder{}
+			order = append(order, current)
+		}
+		queries[current].WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read query file: %w", err)
+	}
+
+	if name == "" {
+		var named []string
+		for _, n := range order {
+			if n != "" {
+				named = append(named, n)
+			}
+		}
+		if len(named) > 1 {
+			return "", fmt.Errorf("query file defines several queries (%s), select one with --query-name", strings.Join(named, ", "))
+		}
+		if len(named) == 1 {
+			name = named[0]
+		}
+	}
+
+	query, ok := queries[name]
+	if !ok || strings.TrimSpace(query.String()) == "" {
+		return "", fmt.Errorf("query %q not found in %s", name, path)
+	}
+	return strings.TrimSpace(query.String()), nil
+}
+
+// fetchResources runs the query, page by page when a page key is set, and
+// maps every row to a resource.
+func fetchResources(ctx context.Context, db *sql.DB, opts queryOptions) ([]api.ResourceProviderResource, error) {
+	var resources []api.ResourceProviderResource
+
+	var after any
+	for {
+		query, args := buildQuery(opts, after, len(resources))
+		page, last, err := queryPage(ctx, db, query, args, opts)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, page...)
+
+		if opts.pageKey == "" || len(page) < opts.pageSize || (opts.limit > 0 && len(resources) >= opts.limit) {
+			break
+		}
+		after = last
+	}
+
+	if opts.limit > 0 && len(resources) > opts.limit {
+		resources = resources[:opts.limit]
+	}
+	return resources, nil
+}
+
+// buildQuery wraps the user query with the row limit and, for keyset
+// pagination, a filter on the page key.
+func buildQuery(opts queryOptions, after any, read int) (string, []any) {
+	query := strings.TrimSuffix(strings.TrimSpace(opts.query), ";")
+	if opts.pageKey == "" {
+		if opts.limit > 0 {
+			return fmt.Sprintf("SELECT * FROM (%s) AS q LIMIT %d", query, opts.limit), nil
+		}
+		return query, nil
+	}
+
+	key := quoteIdentifier(opts.driver, opts.pageKey)
+	size := opts.pageSize
+	if opts.limit > 0 {
+		size = min(size, opts.limit-read)
+	}
+
+	if after == nil {
+		return fmt.Sprintf("SELECT * FROM (%s) AS q ORDER BY q.%s LIMIT %d", query, key, size), nil
+	}
+	placeholder := "?"
+	if opts.driver == "postgres" {
+		placeholder = "$1"
+	}
+	return fmt.Sprintf("SELECT * FROM (%s) AS q WHERE q.%s > %s ORDER BY q.%s LIMIT %d", query, key, placeholder, key, size), []any{after}
+}
+
+func quoteIdentifier(driver, name string) string {
+	if driver == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// queryPage runs one query and returns its resources and the page key value
+// of the last row.
+func queryPage(ctx context.Context, db *sql.DB, query string, args []any, opts queryOptions) ([]api.ResourceProviderResource, any, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	columns := make([]string, len(columnTypes))
+	isJSON := make([]bool, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = ct.Name()
+		dbType := strings.ToUpper(ct.DatabaseTypeName())
+		isJSON[i] = opts.jsonColumns[ct.Name()] || dbType == "JSON" || dbType == "JSONB"
+	}
+
+	fieldColumns := map[string]int{}
+	for _, field := range resourceFields {
+		column := field
+		if mapped, ok := opts.columns[field]; ok {
+			column = mapped
+		}
+		for i, name := range columns {
+			if name == column {
+				fieldColumns[field] = i
+			}
+		}
+		if _, ok := fieldColumns[field]; !ok {
+			if _, mapped := opts.columns[field]; mapped {
+				return nil, nil, fmt.Errorf("column %q mapped to %s is not in the query result", column, field)
+			}
+			if _, ok := opts.defaults[field]; !ok {
+				return nil, nil, fmt.Errorf("query result has no %q column, map one with --column %s=<column> or set --default %s=<value>", field, field, field)
+			}
+		}
+	}
+	isField := map[int]bool{}
+	for _, i := range fieldColumns {
+		isField[i] = true
+	}
+
+	pageKey := -1
+	for i, name := range columns {
+		if name == opts.pageKey {
+			pageKey = i
+		}
+	}
+	if opts.pageKey != "" && pageKey < 0 {
+		return nil, nil, fmt.Errorf("page key column %q is not in the query result", opts.pageKey)
+	}
+
+	var resources []api.ResourceProviderResource
+	var last any
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for row := 1; rows.Next(); row++ {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, fmt.Errorf("failed to read row %d: %w", row, err)
+		}
+		if pageKey >= 0 {
+			last = normalize(values[pageKey])
+		}
+
+		resource := api.ResourceProviderResource{
+			Config:   map[string]any{},
+			Metadata: map[string]string{},
+		}
+		field := func(name string) string {
+			if i, ok := fieldColumns[name]; ok {
+				if value := normalize(values[i]); value != nil {
+					return fmt.Sprint(value)
+				}
+			}
+			return opts.defaults[name]
+		}
+		resource.Name = field("name")
+		resource.Identifier = field("identifier")
+		resource.Kind = field("kind")
+		resource.Version = field("version")
+		for _, name := range resourceFields {
+			if field(name) == "" {
+				return nil, nil, fmt.Errorf("row %d: empty %s", row, name)
+			}
+		}
+
+		for i, raw := range values {
+			value := normalize(raw)
+			if isField[i] || value == nil {
+				continue
+			}
+			if isJSON[i] {
+				if err := mergeJSON(resource.Config, columns[i], value); err != nil {
+					return nil, nil, fmt.Errorf("row %d: column %q: %w", row, columns[i], err)
+				}
+				continue
+			}
+			resource.Metadata[opts.metadataPrefix+columns[i]] = fmt.Sprint(value)
+		}
+
+		resources = append(resources, resource)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return resources, last, nil
+}
+
+// normalize converts driver values to plain Go values.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// mergeJSON decodes a JSON column into config. A column named "config"
+// holding an object is merged into config; any other column is stored under
+// its own name.
+func mergeJSON(config map[string]any, column string, value any) error {
+	var decoded any
+	if err := json.Unmarshal([]byte(fmt.Sprint(value)), &decoded); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if object, ok := decoded.(map[string]any); ok && column == "config" {
+		for key, v := range object {
+			config[key] = v
+		}
+		return nil
+	}
+	config[column] = decoded
+	return nil
+}
@@ -0,0 +1,114 @@
+package sqlsource
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "inventory.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range []string{
+		`create table devices (id integer primary key, hostname text, rack text, attributes text)`,
+		`insert into devices values (1, 'sw-1', 'r1', '{"ports": 48}')`,
+		`insert into devices values (2, 'sw-2', null, '{"ports": 24}')`,
+		`insert into devices values (3, 'sw-3', 'r2', null)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func testOptions() queryOptions {
+	return queryOptions{
+		driver:         "sqlite",
+		query:          "select id, hostname, rack, attributes from devices",
+		columns:        map[string]string{"identifier": "id", "name": "hostname"},
+		defaults:       map[string]string{"kind": "Switch", "version": "lab/v1"},
+		metadataPrefix: "sql/",
+		jsonColumns:    map[string]bool{"attributes": true},
+	}
+}
+
+func TestFetchResources_MapsColumns(t *testing.T) {
+	db := openTestDB(t)
+
+	resources, err := fetchResources(context.Background(), db, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(resources))
+	}
+
+	first := resources[0]
+	if first.Identifier != "1" || first.Name != "sw-1" || first.Kind != "Switch" || first.Version != "lab/v1" {
+		t.Fatalf("unexpected fields: %+v", first)
+	}
+	if first.Metadata["sql/rack"] != "r1" {
+		t.Fatalf("expected prefixed metadata, got %#v", first.Metadata)
+	}
+	attributes, ok := first.Config["attributes"].(map[string]any)
+	if !ok || attributes["ports"] != float64(48) {
+		t.Fatalf("expected decoded JSON config, got %#v", first.Config)
+	}
+	if _, ok := resources[1].Metadata["sql/rack"]; ok {
+		t.Fatalf("expected NULL column to be skipped, got %#v", resources[1].Metadata)
+	}
+}
+
+func TestFetchResources_PaginationAndLimit(t *testing.T) {
+	db := openTestDB(t)
+
+	opts := testOptions()
+	opts.pageKey = "id"
+	opts.pageSize = 2
+	resources, err := fetchResources(context.Background(), db, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 3 || resources[2].Identifier != "3" {
+		t.Fatalf("expected all rows across pages, got %+v", resources)
+	}
+
+	opts.limit = 2
+	opts.pageSize = 1
+	resources, err = fetchResources(context.Background(), db, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("expected the row limit to apply, got %d", len(resources))
+	}
+}
+
+func TestLoadNamedQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.sql")
+	content := "-- name: switches\nselect * from devices where kind = 'switch';\n\n-- name: racks\nselect * from racks;\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := loadNamedQuery(path, "racks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from racks;" {
+		t.Fatalf("unexpected query %q", query)
+	}
+	if _, err := loadNamedQuery(path, ""); err == nil {
+		t.Fatal("expected an error when several queries are defined and none is selected")
+	}
+}
@@ -2,20 +2,28 @@ package sync
 
 import (
 	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/argocd"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/aws"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/azure"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/clickhouse"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/daemon"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/files"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/flux"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/github"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/google"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/helm"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/httpsource"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/kubernetes"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/netbox"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/pipe"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/salesforce"
+	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/sqlsource"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/tailscale"
 	"github.com/ctrlplanedev/cli/cmd/ctrlc/root/sync/terraform"
 	"github.com/ctrlplanedev/cli/internal/cliutil"
+	"github.com/ctrlplanedev/cli/internal/plugin"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func NewSyncCmd() *cobra.Command {
@@ -27,11 +35,56 @@ func NewSyncCmd() *cobra.Command {
 		Example: heredoc.Doc(`
 			$ ctrlc sync tfe --interval 5m # Run every 5 minutes
 			$ ctrlc sync tailscale --interval 1h # Run every hour
+			$ ctrlc sync tfe --interval 5m --continue-on-error --max-failures 10 # Survive transient failures
 			$ ctrlc sync clickhouse # Run once
+			$ ctrlc sync clickhouse --force # Upsert even if nothing changed since the last run
+			$ ctrlc sync aws ec2 --grace-period 30m --interval 5m # Keep instances that briefly disappear
+			$ ctrlc sync helm --on-conflict=fail # Stop if another provider owns an identifier
+			$ ctrlc sync aws eks --notify-webhook "$SLACK_WEBHOOK" --notify-template slack # Post inventory changes
+			$ ctrlc sync aws rds --with-relationships # Also declare RDS -> VPC relationship rules
+			$ ctrlc sync daemon --config sync.yaml # Run many integrations from one config
+			$ ctrlc sync kubernetes controller --watch --helm # Run in-cluster with leader election
+			$ ctrlc sync argocd --interval 5m # Sync Argo CD Applications and ApplicationSets
+			$ ctrlc sync flux --interval 5m # Sync Flux Kustomizations and HelmReleases
+			$ ctrlc sync exec --provider lab --interval 10m -- ./discover.sh # Schedule a discovery script
+			$ ctrlc sync files --provider lab --dir inventory/ --watch # Keep a YAML inventory in sync
+			$ ctrlc sync http --config source.yaml --interval 10m # Sync items from a JSON API
+			$ ctrlc sync sql --provider cmdb --driver postgres --dsn "$DSN" --query-file servers.sql # Sync rows of a query
+			$ ctrlc sync mycmdb --interval 10m # Run the ctrlc-sync-mycmdb plugin found on PATH
 		`),
 	}
 
 	cmd.PersistentFlags().StringVar(&interval, "interval", "", "Run commands on an interval (5m, 1h, 1d)")
+	cmd.PersistentFlags().Bool("with-relationships", false, "Also upsert the relationship rules implied by the synced resources")
+	viper.BindPFlag("with-relationships", cmd.PersistentFlags().Lookup("with-relationships"))
+	viper.BindEnv("with-relationships", "CTRLPLANE_SYNC_WITH_RELATIONSHIPS")
+	cmd.PersistentFlags().Bool("force", false, "Upsert resources even when they are unchanged since the last sync")
+	viper.BindPFlag("force", cmd.PersistentFlags().Lookup("force"))
+	viper.BindEnv("force", "CTRLPLANE_SYNC_FORCE")
+	cmd.PersistentFlags().String("state-dir", "", "Directory for sync state used to detect unchanged resources (default is the user cache directory)")
+	viper.BindPFlag("state-dir", cmd.PersistentFlags().Lookup("state-dir"))
+	viper.BindEnv("state-dir", "CTRLPLANE_SYNC_STATE_DIR")
+	cmd.PersistentFlags().Duration("grace-period", 0, "Keep resources that disappear from a sync for this long, marked with ctrlplane/missing-since")
+	viper.BindPFlag("grace-period", cmd.PersistentFlags().Lookup("grace-period"))
+	viper.BindEnv("grace-period", "CTRLPLANE_SYNC_GRACE_PERIOD")
+	cmd.PersistentFlags().Int("grace-runs", 0, "Keep resources that disappear from a sync for this many runs, marked with ctrlplane/missing-since")
+	viper.BindPFlag("grace-runs", cmd.PersistentFlags().Lookup("grace-runs"))
+	viper.BindEnv("grace-runs", "CTRLPLANE_SYNC_GRACE_RUNS")
+	cmd.PersistentFlags().String("on-conflict", "take-over", "What to do with identifiers owned by another provider: skip, fail or take-over")
+	viper.BindPFlag("on-conflict", cmd.PersistentFlags().Lookup("on-conflict"))
+	viper.BindEnv("on-conflict", "CTRLPLANE_SYNC_ON_CONFLICT")
+	cmd.PersistentFlags().String("notify-webhook", "", "Post the resources added, changed and removed by each sync to this URL")
+	viper.BindPFlag("notify-webhook", cmd.PersistentFlags().Lookup("notify-webhook"))
+	viper.BindEnv("notify-webhook", "CTRLPLANE_SYNC_NOTIFY_WEBHOOK")
+	cmd.PersistentFlags().String("notify-template", "", "Notification payload: 'slack' or a path to a Go template (default is the JSON event)")
+	viper.BindPFlag("notify-template", cmd.PersistentFlags().Lookup("notify-template"))
+	viper.BindEnv("notify-template", "CTRLPLANE_SYNC_NOTIFY_TEMPLATE")
+	cmd.PersistentFlags().StringSlice("notify-kind", nil, "Only notify about resources of these kinds")
+	viper.BindPFlag("notify-kind", cmd.PersistentFlags().Lookup("notify-kind"))
+	viper.BindEnv("notify-kind", "CTRLPLANE_SYNC_NOTIFY_KIND")
+	cmd.PersistentFlags().Int("notify-batch-size", 50, "Maximum number of changes per notification request")
+	viper.BindPFlag("notify-batch-size", cmd.PersistentFlags().Lookup("notify-batch-size"))
+	viper.BindEnv("notify-batch-size", "CTRLPLANE_SYNC_NOTIFY_BATCH_SIZE")
 
 	cmd.AddCommand(cliutil.AddIntervalSupport(terraform.NewSyncTerraformCmd(), ""))
 	cmd.AddCommand(cliutil.AddIntervalSupport(tailscale.NewSyncTailscaleCmd(), ""))
@@ -42,13 +95,34 @@ func NewSyncCmd() *cobra.Command {
 	cmd.AddCommand(cliutil.AddIntervalSupport(kubernetes.NewSyncKubernetesCmd(), ""))
 	cmd.AddCommand(cliutil.AddIntervalSupport(kubernetes.NewSyncVclusterCmd(), ""))
 	cmd.AddCommand(cliutil.AddIntervalSupport(helm.NewSyncHelmCmd(), ""))
+	cmd.AddCommand(cliutil.AddIntervalSupport(argocd.NewSyncArgoCDCmd(), ""))
+	cmd.AddCommand(cliutil.AddIntervalSupport(flux.NewSyncFluxCmd(), ""))
 	cmd.AddCommand(cliutil.AddIntervalSupport(github.NewSyncGitHubCmd(), ""))
 	cmd.AddCommand(cliutil.AddIntervalSupport(salesforce.NewSalesforceCmd(), ""))
 	cmd.AddCommand(cliutil.AddIntervalSupport(netbox.NewNetboxCmd(), ""))
+	cmd.AddCommand(cliutil.AddIntervalSupport(pipe.NewSyncExecCmd(), ""))
+	cmd.AddCommand(cliutil.AddIntervalSupport(files.NewSyncFilesCmd(), ""))
+	cmd.AddCommand(cliutil.AddIntervalSupport(httpsource.NewSyncHTTPCmd(), ""))
+	cmd.AddCommand(cliutil.AddIntervalSupport(sqlsource.NewSyncSQLCmd(), ""))
 
 	// pipe is intentionally not wrapped with AddIntervalSupport -- it is
 	// one-shot by design; the OS scheduler (cron, systemd) handles repetition.
 	cmd.AddCommand(pipe.NewSyncPipeCmd())
 
+	// ctrlc-sync-<name> executables on PATH become "ctrlc sync <name>" unless
+	// a built-in integration already has that name.
+	taken := map[string]bool{}
+	for _, sub := range cmd.Commands() {
+		taken[sub.Name()] = true
+	}
+	for _, p := range plugin.Find(plugin.SyncPrefix) {
+		if !taken[p.Name] {
+			cmd.AddCommand(cliutil.AddIntervalSupport(pipe.NewSyncPluginCmd(p.Name, p.Path), ""))
+		}
+	}
+
+	// daemon schedules the integrations above itself, one child process per run.
+	cmd.AddCommand(daemon.NewSyncDaemonCmd())
+
 	return cmd
 }
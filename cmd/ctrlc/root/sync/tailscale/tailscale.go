@@ -1,7 +1,6 @@
 package tailscale
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -89,7 +88,7 @@ func NewSyncTailscaleCmd() *cobra.Command {
 				}.HTTPClient()
 			}
 
-			ctx := context.Background()
+			ctx := cmd.Context()
 			devices, err := tsc.Devices().List(ctx)
 			if err != nil {
 				return fmt.Errorf("failed to list devices: %w", err)
@@ -0,0 +1,153 @@
+// Package apitest serves a fake ctrlplane API with the resource provider
+// endpoints the syncs use, for tests that need a real API client.
+package apitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+// WorkspaceID is the workspace every request is expected to use.
+const WorkspaceID = "00000000-0000-0000-0000-000000000001"
+
+// ProviderID returns the ID the server gives the provider with the given
+// name.
+func ProviderID(name string) string {
+	return "provider-" + name
+}
+
+// Server is a fake ctrlplane API. Resources set through a provider become
+// owned by it; Owners can be seeded with resources of other providers.
+type Server struct {
+	*httptest.Server
+	Client *api.ClientWithResponses
+
+	// PageSize caps the number of resources returned per search request, so
+	// tests can exercise pagination. Zero honors the requested limit.
+	PageSize int
+
+	mu sync.Mutex
+	// Owners maps resource identifiers to the ID of their provider.
+	Owners map[string]string
+	// Searches records the identifiers of every search request.
+	Searches [][]string
+	// Sets records the resources of every set request, by provider ID.
+	Sets map[string][][]api.ResourceProviderResource
+	// Variables records the last variables set for each identifier.
+	Variables map[string]map[string]any
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		Owners:    map[string]string{},
+		Sets:      map[string][][]api.ResourceProviderResource{},
+		Variables: map[string]map[string]any{},
+	}
+
+	mux := http.NewServeMux()
+	prefix := "/api/v1/workspaces/" + WorkspaceID
+	mux.HandleFunc("PUT "+prefix+"/resource-providers", func(w http.ResponseWriter, r *http.Request) {
+		var body api.RequestResourceProviderUpsertJSONRequestBody
+		if !decode(w, r, &body) {
+			return
+		}
+		reply(w, http.StatusAccepted, api.ResourceProvider{Id: ProviderID(body.Name), Name: body.Name})
+	})
+	mux.HandleFunc("GET "+prefix+"/resource-providers/name/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		reply(w, http.StatusOK, api.ResourceProvider{Id: ProviderID(name), Name: name})
+	})
+	mux.HandleFunc("PUT "+prefix+"/resource-providers/{id}/set", func(w http.ResponseWriter, r *http.Request) {
+		var body api.SetResourceProviderResourcesJSONRequestBody
+		if !decode(w, r, &body) {
+			return
+		}
+		id := r.PathValue("id")
+		s.mu.Lock()
+		s.Sets[id] = append(s.Sets[id], body.Resources)
+		for _, resource := range body.Resources {
+			s.Owners[resource.Identifier] = id
+		}
+		s.mu.Unlock()
+		reply(w, http.StatusAccepted, api.ResourceProviderSetRequestAccepted{Ok: true, Method: "set"})
+	})
+	mux.HandleFunc("PATCH "+prefix+"/resources/identifier/{identifier}/variables", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if !decode(w, r, &body) {
+			return
+		}
+		s.mu.Lock()
+		s.Variables[r.PathValue("identifier")] = body
+		s.mu.Unlock()
+		reply(w, http.StatusAccepted, api.ResourceRequestAccepted{Id: r.PathValue("identifier")})
+	})
+	mux.HandleFunc("POST "+prefix+"/resources/search", s.search)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	client, err := api.NewAPIKeyClientWithResponses(s.URL, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Client = client
+	return s
+}
+
+// search returns the known resources among the requested identifiers, in
+// request order, one page at a time.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	var body api.SearchResourcesJSONRequestBody
+	if !decode(w, r, &body) {
+		return
+	}
+
+	s.mu.Lock()
+	var identifiers []string
+	if body.Identifiers != nil {
+		identifiers = *body.Identifiers
+	}
+	s.Searches = append(s.Searches, identifiers)
+	var found []api.Resource
+	for _, identifier := range identifiers {
+		if owner, ok := s.Owners[identifier]; ok {
+			found = append(found, api.Resource{Identifier: identifier, ProviderId: &owner})
+		}
+	}
+	s.mu.Unlock()
+
+	offset, limit := 0, len(found)
+	if body.Offset != nil {
+		offset = min(*body.Offset, len(found))
+	}
+	if body.Limit != nil {
+		limit = *body.Limit
+	}
+	if s.PageSize > 0 {
+		limit = min(limit, s.PageSize)
+	}
+	page := found[offset:min(offset+limit, len(found))]
+
+	reply(w, http.StatusOK, map[string]any{"items": page, "limit": limit, "offset": offset, "total": len(found)})
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func reply(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -141,7 +141,7 @@ func (s Selector) CelExpression() string {
 type Result struct {
 	Type   string
 	Name   string
-	Action string // "created", "updated", "unchanged", "upserted"
+	Action string // "created", "updated", "unchanged", "upserted", "skipped"
 	ID     string
 	Error  error
 }
@@ -3,11 +3,10 @@ package providers
 import (
 	"fmt"
 	"net/http"
-	"time"
 
-	"github.com/avast/retry-go"
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
 	"gopkg.in/yaml.v3"
 )
 
@@ -160,7 +159,9 @@ func (r *ResourceItemSpec) getProviderID(ctx Context) (string, error) {
 // calls replace the entire provider's resource set.
 // Resources with no provider are upserted individually via the regular
 // resource upsert endpoint (PATCH /resources/identifier/{identifier}).
-func BatchUpsertResources(ctx Context, specs []*ResourceItemSpec) []Result {
+// Identifiers already owned by a different provider are handled according
+// to the conflict policy before the group is upserted.
+func BatchUpsertResources(ctx Context, specs []*ResourceItemSpec, policy resourceprovider.ConflictPolicy) []Result {
 	var noProviderSpecs []*ResourceItemSpec
 	byProvider := make(map[string][]*ResourceItemSpec)
 	for _, spec := range specs {
@@ -211,6 +212,29 @@ func BatchUpsertResources(ctx Context, specs []*ResourceItemSpec) []Result {
 			})
 		}
 
+		identifiers := make([]string, 0, len(apiResources))
+		for _, resource := range apiResources {
+			identifiers = append(identifiers, resource.Identifier)
+		}
+		conflicts, err := resourceprovider.FindConflicts(ctx.Ctx(), ctx.APIClient(), ctx.WorkspaceIDValue(), providerID, identifiers)
+		if err == nil {
+			apiResources, err = resourceprovider.ResolveConflicts(providerName, apiResources, conflicts, policy)
+		}
+		if err != nil {
+			for _, spec := range group {
+				results = append(results, Result{
+					Type:  resourceTypeName,
+					Name:  spec.DisplayName,
+					Error: err,
+				})
+			}
+			continue
+		}
+		kept := make(map[string]bool, len(apiResources))
+		for _, resource := range apiResources {
+			kept[resource.Identifier] = true
+		}
+
 		log.Debug("Upserting resources", "workspaceID", ctx.WorkspaceIDValue(), "provider", providerName, "providerID", providerID)
 		resp, err := ctx.APIClient().SetResourceProviderResourcesWithResponse(
 			ctx.Ctx(), ctx.WorkspaceIDValue(), providerID,
@@ -238,6 +262,15 @@ func BatchUpsertResources(ctx Context, specs []*ResourceItemSpec) []Result {
 		}
 
 		for _, spec := range group {
+			if !kept[spec.Identifier] {
+				results = append(results, Result{
+					Type:   resourceTypeName,
+					Name:   spec.DisplayName,
+					ID:     spec.Identifier,
+					Action: "skipped",
+				})
+				continue
+			}
 			result := Result{
 				Type:   resourceTypeName,
 				Name:   spec.DisplayName,
@@ -298,32 +331,5 @@ func (r *ResourceItemSpec) upsertWithoutProvider(ctx Context) Result {
 }
 
 func (r *ResourceItemSpec) syncVariables(ctx Context) error {
-	vars := r.Variables
-	if vars == nil {
-		vars = make(map[string]any)
-	}
-
-	err := retry.Do(
-		func() error {
-			varsResp, err := ctx.APIClient().RequestResourceVariablesUpdateWithResponse(ctx.Ctx(), ctx.WorkspaceIDValue(), r.Identifier, api.RequestResourceVariablesUpdateJSONRequestBody(vars))
-			if err != nil {
-				return retry.Unrecoverable(fmt.Errorf("failed to update resource variables: %w", err))
-			}
-			if varsResp == nil {
-				return retry.Unrecoverable(fmt.Errorf("failed to update resource variables: empty response"))
-			}
-			if varsResp.StatusCode() == 404 {
-				return fmt.Errorf("resource not found yet, retrying")
-			}
-			if varsResp.StatusCode() != 202 {
-				return retry.Unrecoverable(fmt.Errorf("failed to update resource variables: %s", string(varsResp.Body)))
-			}
-			return nil
-		},
-		retry.Attempts(10),
-		retry.Delay(100*time.Millisecond),
-		retry.MaxDelay(15*time.Second),
-		retry.DelayType(retry.BackOffDelay),
-	)
-	return err
+	return resourceprovider.UpdateResourceVariables(ctx.Ctx(), ctx.APIClient(), ctx.WorkspaceIDValue(), r.Identifier, r.Variables)
 }
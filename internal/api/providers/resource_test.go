@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/api/apitest"
+	"github.com/ctrlplanedev/cli/internal/api/resolver"
+	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
+)
+
+type testContext struct {
+	client *api.ClientWithResponses
+}
+
+func (c testContext) Ctx() context.Context                    { return context.Background() }
+func (c testContext) WorkspaceIDValue() string                { return apitest.WorkspaceID }
+func (c testContext) APIClient() *api.ClientWithResponses     { return c.client }
+func (c testContext) ResolverProvider() *resolver.APIResolver { return nil }
+
+func TestBatchUpsertResources_Conflicts(t *testing.T) {
+	specs := func() []*ResourceItemSpec {
+		return []*ResourceItemSpec{
+			{DisplayName: "a", Identifier: "a", Kind: "Server", Version: "v1", Provider: "mine"},
+			{DisplayName: "b", Identifier: "b", Kind: "Server", Version: "v1", Provider: "mine"},
+		}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		policy  resourceprovider.ConflictPolicy
+		actions map[string]string
+		sent    int
+		wantErr bool
+	}{
+		{name: "take over", policy: resourceprovider.ConflictTakeOver, actions: map[string]string{"a": "upserted", "b": "upserted"}, sent: 2},
+		{name: "skip", policy: resourceprovider.ConflictSkip, actions: map[string]string{"a": "upserted", "b": "skipped"}, sent: 1},
+		{name: "fail", policy: resourceprovider.ConflictFail, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := apitest.NewServer(t)
+			server.Owners["b"] = apitest.ProviderID("other")
+
+			results := BatchUpsertResources(testContext{client: server.Client}, specs(), tt.policy)
+			if len(results) != 2 {
+				t.Fatalf("results = %d, want 2", len(results))
+			}
+			for _, result := range results {
+				if tt.wantErr {
+					if result.Error == nil {
+						t.Errorf("%s: expected an error", result.Name)
+					}
+					continue
+				}
+				if result.Error != nil {
+					t.Errorf("%s: %v", result.Name, result.Error)
+				}
+				if result.Action != tt.actions[result.Name] {
+					t.Errorf("%s: action = %q, want %q", result.Name, result.Action, tt.actions[result.Name])
+				}
+			}
+
+			sets := server.Sets[apitest.ProviderID("mine")]
+			if tt.wantErr {
+				if len(sets) != 0 {
+					t.Errorf("expected nothing to be upserted, got %d set requests", len(sets))
+				}
+				return
+			}
+			if len(sets) != 1 || len(sets[0]) != tt.sent {
+				t.Errorf("set requests = %v, want one with %d resources", sets, tt.sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package cliutil
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before retry number `attempt` (starting at 1),
+// doubling from base and capped at max.
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max || delay <= 0 {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// Jitter returns a random duration in [0, max). It returns 0 when max is not
+// positive.
+func Jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
@@ -0,0 +1,45 @@
+package cliutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    time.Duration
+		max     time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{"first attempt is the base", 10 * time.Second, 5 * time.Minute, 1, 10 * time.Second},
+		{"attempts below one count as the first", 10 * time.Second, 5 * time.Minute, 0, 10 * time.Second},
+		{"doubles per attempt", 10 * time.Second, 5 * time.Minute, 3, 40 * time.Second},
+		{"capped at max", 10 * time.Second, 5 * time.Minute, 6, 5 * time.Minute},
+		{"stays capped", 10 * time.Second, 5 * time.Minute, 100, 5 * time.Minute},
+		{"base above max is capped", 10 * time.Minute, 5 * time.Minute, 1, 5 * time.Minute},
+		{"overflow is capped", time.Hour, time.Duration(1<<62 + 1), 64, time.Duration(1<<62 + 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Backoff(tt.base, tt.max, tt.attempt); got != tt.want {
+				t.Errorf("Backoff(%s, %s, %d) = %s, want %s", tt.base, tt.max, tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if got := Jitter(0); got != 0 {
+		t.Errorf("Jitter(0) = %s, want 0", got)
+	}
+	if got := Jitter(-time.Second); got != 0 {
+		t.Errorf("Jitter(-1s) = %s, want 0", got)
+	}
+	for range 100 {
+		if got := Jitter(time.Second); got < 0 || got >= time.Second {
+			t.Fatalf("Jitter(1s) = %s, want [0, 1s)", got)
+		}
+	}
+}
@@ -1,51 +1,160 @@
 package cliutil
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 )
 
+// intervalRetryDelay is the first backoff delay after a failed iteration when
+// --continue-on-error is set.
+const intervalRetryDelay = 10 * time.Second
+
+type intervalOptions struct {
+	interval        string
+	continueOnError bool
+	maxFailures     int
+	maxBackoff      time.Duration
+	runTimeout      time.Duration
+	// retryDelay is the first backoff delay; intervalRetryDelay outside
+	// tests.
+	retryDelay time.Duration
+}
+
+// AddIntervalSupport adds --interval and related flags to cmd. With an
+// interval set, the command's RunE is repeated until the process receives
+// SIGINT/SIGTERM. Each iteration runs with cmd.Context() bound to a
+// per-iteration context, so --run-timeout cancels in-flight API calls for
+// commands that use it. A signal lets the current iteration finish and stops
+// the loop before the next one; a second signal aborts immediately.
 func AddIntervalSupport(cmd *cobra.Command, defaultInterval string) *cobra.Command {
-	var interval string
+	opts := intervalOptions{retryDelay: intervalRetryDelay}
 
 	run := cmd.RunE
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if interval != "" {
-			log.Info("Running command on interval", "interval", interval)
-			duration, err := time.ParseDuration(interval)
-			if err != nil {
-				log.Error("Failed to parse interval duration", "error", err)
+		if opts.interval == "" {
+			ctx, cancel := iterationContext(cmd.Context(), opts.runTimeout)
+			defer cancel()
+			return runWithContext(ctx, cmd, args, run)
+		}
+
+		log.Info("Running command on interval", "interval", opts.interval)
+		duration, err := time.ParseDuration(opts.interval)
+		if err != nil {
+			log.Error("Failed to parse interval duration", "error", err)
+			return err
+		}
+		return runInterval(cmd, args, run, duration, opts)
+	}
+
+	cmd.Flags().StringVarP(&opts.interval, "interval", "i", defaultInterval, "Run commands on an interval (5m, 1h, 1d)")
+	cmd.Flags().BoolVar(&opts.continueOnError, "continue-on-error", false, "Keep running on the interval when an iteration fails, retrying with exponential backoff")
+	cmd.Flags().IntVar(&opts.maxFailures, "max-failures", 0, "Exit after this many consecutive failed iterations when --continue-on-error is set (0 = never)")
+	cmd.Flags().DurationVar(&opts.maxBackoff, "max-backoff", 5*time.Minute, "Maximum delay between retries of failed iterations")
+	cmd.Flags().DurationVar(&opts.runTimeout, "run-timeout", 0, "Cancel an iteration that runs longer than this (0 = no timeout)")
+
+	return cmd
+}
+
+func runInterval(cmd *cobra.Command, args []string, run func(*cobra.Command, []string) error, interval time.Duration, opts intervalOptions) error {
+	parent := cmd.Context()
+	defer cmd.SetContext(parent)
+
+	// The first signal stops the loop after the current iteration; the default
+	// handler is restored so a second signal aborts immediately.
+	shutdown, requestShutdown := context.WithCancel(parent)
+	defer requestShutdown()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Info("Received signal, stopping after the current iteration", "signal", sig)
+			signal.Stop(signals)
+			requestShutdown()
+		case <-shutdown.Done():
+		}
+	}()
+
+	iteration := uint64(1)
+	failures := 0
+	for {
+		log.Info(">>> Starting iteration", "number", iteration)
+		startTime := time.Now()
+
+		iterCtx, cancel := iterationContext(parent, opts.runTimeout)
+		err := runWithContext(iterCtx, cmd, args, run)
+		if err != nil && iterCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("iteration timed out after %s: %w", opts.runTimeout, err)
+		}
+		cancel()
+
+		elapsed := time.Since(startTime)
+		wait := interval
+		status := "success"
+		if err != nil {
+			failures++
+			status = "failure"
+			if !opts.continueOnError {
+				log.Error("Command failed", "error", err, "iteration", iteration)
 				return err
 			}
-
-			iteration := uint64(1)
-			for {
-				log.Info(">>> Starting iteration", "number", iteration)
-				startTime := time.Now()
-
-				if err := run(cmd, args); err != nil {
-					log.Error("Command failed", "error", err, "iteration", iteration)
-					return err
-				}
-
-				elapsed := time.Since(startTime)
-				log.Info("<<< Iteration complete",
-					"number", iteration,
-					"duration", elapsed,
-					"next_run", time.Now().Add(duration),
+			if opts.maxFailures > 0 && failures >= opts.maxFailures {
+				log.Error("Command failed too many times in a row, giving up",
+					"error", err,
+					"iteration", iteration,
+					"consecutive_failures", failures,
 				)
-
-				time.Sleep(duration)
-				iteration++
+				return err
 			}
+			wait = min(Backoff(opts.retryDelay, opts.maxBackoff, failures), interval)
+		} else {
+			failures = 0
+		}
+
+		logSummary := log.Info
+		if err != nil {
+			logSummary = log.Warn
+		}
+		logSummary("<<< Iteration complete",
+			"number", iteration,
+			"status", status,
+			"duration", elapsed,
+			"error", err,
+			"consecutive_failures", failures,
+			"next_run", time.Now().Add(wait),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-shutdown.Done():
+			timer.Stop()
+			log.Info("Shutting down interval loop", "iterations", iteration)
+			return nil
+		case <-timer.C:
 		}
-		return run(cmd, args)
+		iteration++
 	}
+}
 
-	cmd.Flags().StringVarP(&interval, "interval", "i", defaultInterval, "Run commands on an interval (5m, 1h, 1d)")
+func iterationContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
 
-	return cmd
+func runWithContext(ctx context.Context, cmd *cobra.Command, args []string, run func(*cobra.Command, []string) error) error {
+	parent := cmd.Context()
+	cmd.SetContext(ctx)
+	defer cmd.SetContext(parent)
+	return run(cmd, args)
 }
@@ -0,0 +1,186 @@
+package cliutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunInterval(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name string
+		opts intervalOptions
+		// results are returned by successive iterations; the parent context
+		// is cancelled after the last one.
+		results   []error
+		wantRuns  int
+		wantError string
+	}{
+		{
+			name:      "a failure stops the loop by default",
+			results:   []error{nil, errFailed, nil},
+			wantRuns:  2,
+			wantError: "failed",
+		},
+		{
+			name:     "continue-on-error keeps running",
+			opts:     intervalOptions{continueOnError: true},
+			results:  []error{errFailed, errFailed, nil, errFailed},
+			wantRuns: 4,
+		},
+		{
+			name:      "gives up after max-failures consecutive failures",
+			opts:      intervalOptions{continueOnError: true, maxFailures: 3},
+			results:   []error{errFailed, errFailed, errFailed, nil},
+			wantRuns:  3,
+			wantError: "failed",
+		},
+		{
+			name:     "a success resets the consecutive failures",
+			opts:     intervalOptions{continueOnError: true, maxFailures: 2},
+			results:  []error{errFailed, nil, errFailed, nil},
+			wantRuns: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			runs := 0
+			run := func(cmd *cobra.Command, args []string) error {
+				err := tt.results[runs]
+				runs++
+				if runs == len(tt.results) {
+					cancel()
+				}
+				return err
+			}
+
+			cmd := &cobra.Command{}
+			cmd.SetContext(ctx)
+			tt.opts.retryDelay = time.Millisecond
+			tt.opts.maxBackoff = time.Millisecond
+			err := runInterval(cmd, nil, run, time.Millisecond, tt.opts)
+
+			if runs != tt.wantRuns {
+				t.Errorf("ran %d iterations, want %d", runs, tt.wantRuns)
+			}
+			if tt.wantError == "" && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.wantError != "" && (err == nil || !strings.Contains(err.Error(), tt.wantError)) {
+				t.Errorf("error = %v, want %q", err, tt.wantError)
+			}
+			if cmd.Context() != ctx {
+				t.Error("expected the command context to be restored")
+			}
+		})
+	}
+}
+
+func TestRunInterval_Timeout(t *testing.T) {
+	runs := 0
+	run := func(cmd *cobra.Command, args []string) error {
+		runs++
+		<-cmd.Context().Done()
+		return cmd.Context().Err()
+	}
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	err := runInterval(cmd, nil, run, time.Hour, intervalOptions{
+		continueOnError: true,
+		maxFailures:     2,
+		runTimeout:      10 * time.Millisecond,
+		retryDelay:      time.Millisecond,
+		maxBackoff:      time.Millisecond,
+	})
+	if runs != 2 {
+		t.Errorf("ran %d iterations, want 2", runs)
+	}
+	if err == nil || !strings.Contains(err.Error(), "iteration timed out after 10ms") || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want a timed out iteration", err)
+	}
+}
+
+func TestRunInterval_BackoffCappedByInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var starts []time.Time
+	run := func(cmd *cobra.Command, args []string) error {
+		starts = append(starts, time.Now())
+		if len(starts) == 2 {
+			cancel()
+		}
+		return errors.New("failed")
+	}
+	cmd := &cobra.Command{}
+	cmd.SetContext(ctx)
+
+	// The backoff would wait an hour; the interval is much shorter.
+	done := make(chan error, 1)
+	go func() {
+		done <- runInterval(cmd, nil, run, 20*time.Millisecond, intervalOptions{
+			continueOnError: true,
+			retryDelay:      time.Hour,
+			maxBackoff:      time.Hour,
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the retry to wait at most the interval")
+	}
+	if len(starts) != 2 {
+		t.Errorf("ran %d iterations, want 2", len(starts))
+	}
+}
+
+func TestRunInterval_SignalFinishesIteration(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("os.Interrupt cannot be sent on Windows")
+	}
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := 0
+	run := func(cmd *cobra.Command, args []string) error {
+		runs++
+		if err := process.Signal(os.Interrupt); err != nil {
+			return err
+		}
+		// Give the loop time to handle the signal; the iteration must not be
+		// cancelled by it.
+		time.Sleep(100 * time.Millisecond)
+		return cmd.Context().Err()
+	}
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runInterval(cmd, nil, run, time.Hour, intervalOptions{})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected the iteration to finish uncancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the loop to stop instead of waiting for the next iteration")
+	}
+	if runs != 1 {
+		t.Errorf("ran %d iterations, want 1", runs)
+	}
+}
@@ -3,18 +3,86 @@ package common
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/cliutil"
+	"github.com/ctrlplanedev/cli/internal/notify"
 	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-func UpsertResources(ctx context.Context, resources []api.ResourceProviderResource, name *string) error {
+// UpsertOption configures optional behavior of UpsertResources.
+type UpsertOption func(*upsertOptions)
+
+type upsertOptions struct {
+	relationshipRules []api.UpsertRelationshipRuleRequest
+	variables         map[string]map[string]any
+	output            *cobra.Command
+	force             bool
+}
+
+// WithRelationshipRules declares the relationship rules implied by the synced
+// resources. They are only upserted when --with-relationships is set.
+func WithRelationshipRules(rules ...api.UpsertRelationshipRuleRequest) UpsertOption {
+	return func(o *upsertOptions) {
+		o.relationshipRules = append(o.relationshipRules, rules...)
+	}
+}
+
+// WithVariables sets resource variables, keyed by resource identifier. They
+// are pushed after the resources are upserted, for resources that are new or
+// changed since the last sync.
+func WithVariables(variables map[string]map[string]any) UpsertOption {
+	return func(o *upsertOptions) {
+		if o.variables == nil {
+			o.variables = make(map[string]map[string]any, len(variables))
+		}
+		for identifier, vars := range variables {
+			o.variables[identifier] = vars
+		}
+	}
+}
+
+// WithForce sends the resources even when they match the last sync, as
+// --force does.
+func WithForce(force bool) UpsertOption {
+	return func(o *upsertOptions) {
+		o.force = o.force || force
+	}
+}
+
+// WithOutput writes the upsert response with the command's --format and
+// --template flags, as cliutil.HandleResponseOutput does. Nothing is written
+// under --interval or when the resources were unchanged and nothing was
+// sent; commands using it say so in their help.
+func WithOutput(cmd *cobra.Command) UpsertOption {
+	return func(o *upsertOptions) {
+		o.output = cmd
+	}
+}
+
+// UpsertResources syncs the resources to the named provider with the
+// behavior selected by the sync flags (--force, --state-dir, --grace-period,
+// --on-conflict, --with-relationships, --notify-webhook).
+func UpsertResources(ctx context.Context, resources []api.ResourceProviderResource, name *string, opts ...UpsertOption) error {
 	if name == nil || *name == "" {
 		return fmt.Errorf("name is unset, invalid usage")
 	}
 
+	options := upsertOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	policy, err := resourceprovider.ParseConflictPolicy(viper.GetString("on-conflict"))
+	if err != nil {
+		return err
+	}
+
 	apiURL := viper.GetString("url")
 	apiKey := viper.GetString("api-key")
 	workspaceId := viper.GetString("workspace")
@@ -29,11 +97,74 @@ func UpsertResources(ctx context.Context, resources []api.ResourceProviderResour
 		return fmt.Errorf("failed to create resource provider: %w", err)
 	}
 
-	upsertResp, err := rp.UpsertResource(ctx, resources)
+	syncOpts := []resourceprovider.SyncOption{
+		resourceprovider.WithVariables(options.variables),
+		resourceprovider.WithStateDir(viper.GetString("state-dir")),
+		resourceprovider.WithForce(options.force || viper.GetBool("force")),
+		resourceprovider.WithGracePeriod(viper.GetDuration("grace-period"), viper.GetInt("grace-runs")),
+		resourceprovider.WithConflictPolicy(policy),
+	}
+	if viper.GetBool("with-relationships") {
+		syncOpts = append(syncOpts, resourceprovider.WithRelationshipRules(options.relationshipRules...))
+	}
+
+	result, err := rp.Sync(ctx, resources, syncOpts...)
 	if err != nil {
-		return fmt.Errorf("failed to upsert resources: %w", err)
+		return err
+	}
+
+	// The first sync of a provider would report everything as added, so
+	// notifications start with the second one.
+	if !result.FirstSync {
+		notifyChanges(ctx, rp.Name, workspaceId, result.Changes)
 	}
 
-	log.Info("Successfully upserted resources", "status", upsertResp.Status, "count", len(resources))
+	if options.output != nil && result.Response != nil {
+		if interval, _ := options.output.Flags().GetString("interval"); interval == "" {
+			return cliutil.HandleOutput(options.output, result.Response)
+		}
+	}
 	return nil
 }
+
+// notifyChanges posts the diff of a successful sync to --notify-webhook. A
+// failed notification is logged and does not fail the sync.
+func notifyChanges(ctx context.Context, provider string, workspaceId string, changes []resourceprovider.Change) {
+	url := viper.GetString("notify-webhook")
+	if url == "" || len(changes) == 0 {
+		return
+	}
+
+	tmpl, err := notify.ParseTemplate(viper.GetString("notify-template"))
+	if err != nil {
+		log.Warn("Not sending change notification", "provider", provider, "error", err)
+		return
+	}
+
+	notifier := notify.Notifier{
+		URL:       url,
+		Kinds:     notifyKinds(),
+		BatchSize: viper.GetInt("notify-batch-size"),
+		Template:  tmpl,
+	}
+	event := notify.Event{Provider: provider, Workspace: workspaceId, Timestamp: time.Now().UTC(), Changes: changes}
+	if err := notifier.Send(ctx, event); err != nil {
+		log.Warn("Failed to send change notification", "provider", provider, "error", err)
+		return
+	}
+	log.Debug("Sent change notification", "provider", provider, "changes", len(changes))
+}
+
+// notifyKinds returns the --notify-kind values. CTRLPLANE_SYNC_NOTIFY_KIND is
+// a single string, so kinds are also split on commas there.
+func notifyKinds() []string {
+	var kinds []string
+	for _, value := range viper.GetStringSlice("notify-kind") {
+		for _, kind := range strings.Split(value, ",") {
+			if kind = strings.TrimSpace(kind); kind != "" {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,67 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/api/apitest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestNotifyKinds_FromEnv(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.BindEnv("notify-kind", "CTRLPLANE_SYNC_NOTIFY_KIND")
+	t.Setenv("CTRLPLANE_SYNC_NOTIFY_KIND", "KubernetesDeployment, KubernetesNamespace")
+
+	want := []string{"KubernetesDeployment", "KubernetesNamespace"}
+	if got := notifyKinds(); !slices.Equal(got, want) {
+		t.Errorf("kinds = %v, want %v", got, want)
+	}
+}
+
+func TestWithOutput(t *testing.T) {
+	server := apitest.NewServer(t)
+	t.Cleanup(viper.Reset)
+	viper.Set("url", server.URL)
+	viper.Set("api-key", "test")
+	viper.Set("workspace", apitest.WorkspaceID)
+	viper.Set("state-dir", t.TempDir())
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.Flags().String("format", "json", "")
+	cmd.Flags().String("template", "", "")
+	cmd.Flags().String("interval", "", "")
+	cmd.SetOut(&out)
+
+	name := "ec2"
+	resources := []api.ResourceProviderResource{
+		{Name: "web", Identifier: "i-web", Kind: "Instance", Version: "compute/v1", Config: map[string]any{}, Metadata: map[string]string{}},
+	}
+	sync := func(opts ...UpsertOption) string {
+		t.Helper()
+		out.Reset()
+		if err := UpsertResources(context.Background(), resources, &name, append(opts, WithOutput(cmd))...); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	if sync() == "" {
+		t.Error("expected the response of a sync that sent resources")
+	}
+	if got := sync(); got != "" {
+		t.Errorf("expected nothing for an unchanged sync, got %q", got)
+	}
+	if sync(WithForce(true)) == "" {
+		t.Error("expected the response of a forced sync")
+	}
+	cmd.Flags().Set("interval", "5m")
+	if got := sync(WithForce(true)); got != "" {
+		t.Errorf("expected nothing under --interval, got %q", got)
+	}
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+// ClusterVariables exposes the API server endpoint and CA of
+// ctrlplane.dev/kubernetes/cluster/v1 resources as resource variables, read
+// from their "server" config.
+func ClusterVariables(resources []api.ResourceProviderResource) map[string]map[string]any {
+	variables := make(map[string]map[string]any, len(resources))
+	for _, resource := range resources {
+		server, ok := resource.Config["server"].(map[string]any)
+		if !ok {
+			continue
+		}
+		vars := map[string]any{}
+		if endpoint, ok := server["endpoint"]; ok {
+			vars["endpoint"] = endpoint
+		}
+		if ca, ok := server["certificateAuthorityData"]; ok && ca != "" {
+			vars["certificateAuthorityData"] = ca
+		}
+		variables[resource.Identifier] = vars
+	}
+	return variables
+}
+
+// DatabaseVariables exposes the host and port of ctrlplane.dev/database/v1
+// resources as resource variables, read from their config.
+func DatabaseVariables(resources []api.ResourceProviderResource) map[string]map[string]any {
+	variables := make(map[string]map[string]any, len(resources))
+	for _, resource := range resources {
+		vars := map[string]any{}
+		for _, key := range []string{"host", "port"} {
+			if value, ok := resource.Config[key]; ok {
+				vars[key] = value
+			}
+		}
+		if len(vars) > 0 {
+			variables[resource.Identifier] = vars
+		}
+	}
+	return variables
+}
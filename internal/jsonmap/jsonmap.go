@@ -0,0 +1,178 @@
+// Package jsonmap maps decoded JSON documents to resources with JSONPath
+// templates. It backs the declarative sources such as `ctrlc sync http` and
+// the generic Kubernetes sync.
+package jsonmap
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// singleExpression matches a template that is exactly one JSONPath
+// expression, whose value can then keep its JSON type.
+var singleExpression = regexp.MustCompile(`^\{[^{}]*\}$`)
+
+// Mapping turns an item into a resource. Every value is a JSONPath template
+// evaluated against the item: text outside braces is kept, so
+// "cmdb/{.id}" and the constant "Server" are both valid. Config values that
+// are a single expression keep the item's JSON type.
+type Mapping struct {
+	Name       string `yaml:"name"`
+	Identifier string `yaml:"identifier"`
+	Kind       string `yaml:"kind"`
+	Version    string `yaml:"version"`
+	// Config is either a single expression producing an object, e.g. "{.}",
+	// or a map of config keys to expressions.
+	Config   any               `yaml:"config"`
+	Metadata map[string]string `yaml:"metadata"`
+}
+
+// Validate checks that the required fields are set and that Config has a
+// supported shape.
+func (m Mapping) Validate(prefix string) error {
+	for _, field := range []struct{ name, value string }{
+		{"name", m.Name},
+		{"identifier", m.Identifier},
+		{"kind", m.Kind},
+		{"version", m.Version},
+	} {
+		if field.value == "" {
+			return fmt.Errorf("missing required field '%s%s'", prefix, field.name)
+		}
+	}
+	switch m.Config.(type) {
+	case nil, string, map[string]any:
+	default:
+		return fmt.Errorf("%sconfig must be an expression or a map of expressions", prefix)
+	}
+	return nil
+}
+
+// Apply evaluates the mapping against item.
+func (m Mapping) Apply(item any) (api.ResourceProviderResource, error) {
+	resource := api.ResourceProviderResource{
+		Config:   map[string]any{},
+		Metadata: map[string]string{},
+	}
+
+	fields := []struct {
+		name     string
+		template string
+		target   *string
+	}{
+		{"name", m.Name, &resource.Name},
+		{"identifier", m.Identifier, &resource.Identifier},
+		{"kind", m.Kind, &resource.Kind},
+		{"version", m.Version, &resource.Version},
+	}
+	for _, field := range fields {
+		value, err := Evaluate(field.template, item)
+		if err != nil {
+			return resource, fmt.Errorf("%s: %w", field.name, err)
+		}
+		if value == "" {
+			return resource, fmt.Errorf("%s: %q produced an empty value", field.name, field.template)
+		}
+		*field.target = value
+	}
+
+	switch config := m.Config.(type) {
+	case string:
+		value, err := EvaluateValue(config, item)
+		if err != nil {
+			return resource, fmt.Errorf("config: %w", err)
+		}
+		object, ok := value.(map[string]any)
+		if !ok {
+			return resource, fmt.Errorf("config: %q did not produce an object", config)
+		}
+		resource.Config = object
+	case map[string]any:
+		for key, raw := range config {
+			template, ok := raw.(string)
+			if !ok {
+				resource.Config[key] = raw
+				continue
+			}
+			value, err := EvaluateValue(template, item)
+			if err != nil {
+				return resource, fmt.Errorf("config.%s: %w", key, err)
+			}
+			if value != nil {
+				resource.Config[key] = value
+			}
+		}
+	}
+
+	for key, template := range m.Metadata {
+		value, err := Evaluate(template, item)
+		if err != nil {
+			return resource, fmt.Errorf("metadata.%s: %w", key, err)
+		}
+		if value != "" {
+			resource.Metadata[key] = value
+		}
+	}
+
+	return resource, nil
+}
+
+// Evaluate renders a JSONPath template as text. Missing keys render empty.
+func Evaluate(template string, data any) (string, error) {
+	jp := jsonpath.New("mapping").AllowMissingKeys(true)
+	if err := jp.Parse(template); err != nil {
+		return "", fmt.Errorf("invalid expression %q: %w", template, err)
+	}
+	var out bytes.Buffer
+	if err := jp.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to evaluate %q: %w", template, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// EvaluateValue keeps the JSON type of single-expression templates and
+// renders anything else as text.
+func EvaluateValue(template string, data any) (any, error) {
+	if !singleExpression.MatchString(template) {
+		return Evaluate(template, data)
+	}
+	values, err := Find(template, data)
+	if err != nil {
+		return nil, err
+	}
+	switch len(values) {
+	case 0:
+		return nil, nil
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
+
+// Find returns every value matched by a JSONPath expression.
+func Find(path string, data any) ([]any, error) {
+	jp := jsonpath.New("select").AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", path, err)
+	}
+	results, err := jp.FindResults(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %w", path, err)
+	}
+
+	var values []any
+	for _, result := range results {
+		for _, value := range result {
+			if value.IsValid() && value.CanInterface() {
+				values = append(values, value.Interface())
+			}
+		}
+	}
+	return values, nil
+}
@@ -71,6 +71,9 @@ const (
 	K8SMetadataIngressRules   = "kubernetes/ingress-rules"
 	K8SMetadataIngressClass   = "kubernetes/ingress-class"
 	K8SMetadataFlavor         = "kubernetes/flavor"
+	// K8SMetadataServerHost is the API server as kubeconfig.ServerHost
+	// normalizes it, comparable across cluster syncs.
+	K8SMetadataServerHost = "kubernetes/server-host"
 )
 
 const (
@@ -94,6 +97,9 @@ const (
 	VClusterMetadataNamespace    = "vcluster/namespace"
 	VClusterMetadataStatus       = "vcluster/status"
 	VClusterMetadataCreated      = "vcluster/created"
+
+	VClusterMetadataParentCluster     = "vcluster/parent-cluster"
+	VClusterMetadataParentClusterName = "vcluster/parent-cluster-name"
 )
 
 const (
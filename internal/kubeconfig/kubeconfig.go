@@ -0,0 +1,227 @@
+// Package kubeconfig resolves the Kubernetes client configuration shared by
+// the kubernetes, vcluster and helm syncs.
+package kubeconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// InCluster reports whether the process runs in a pod with a service
+// account, i.e. whether rest.InClusterConfig can succeed.
+func InCluster() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" || os.Getenv("KUBERNETES_SERVICE_PORT") == "" {
+		return false
+	}
+	_, err := os.Stat(serviceAccountNamespaceFile)
+	return err == nil
+}
+
+// Path returns the kubeconfig file Load uses: $KUBECONFIG, or
+// ~/.kube/config when it exists and the process is not running in a pod. It
+// is empty when the configuration does not come from a file.
+func Path() string {
+	if path := os.Getenv("KUBECONFIG"); path != "" {
+		return path
+	}
+	if InCluster() {
+		return ""
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(homeDir, ".kube", "config")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// Load returns the client configuration and a suggested cluster name. It
+// tries, in order:
+//  1. the KUBECONFIG environment variable
+//  2. the in-cluster service account, when running in a pod
+//  3. the default location (~/.kube/config)
+//
+// The cluster name is the kubeconfig's current context, or the pod's
+// namespace in-cluster.
+func Load() (*rest.Config, string, error) {
+	if path := Path(); path != "" {
+		log.Info("Loading kubeconfig", "path", path)
+		return loadFile(path)
+	}
+
+	log.Info("Loading in-cluster kubeconfig")
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	return config, inClusterName(), nil
+}
+
+// Namespace returns the namespace of the pod's service account, or "" when
+// not running in a pod. POD_NAMESPACE, usually set with the downward API,
+// takes precedence.
+func Namespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	data, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ServerHost normalizes an API server address for comparison: the scheme,
+// path and default https port are dropped and the host is lowercased, so
+// "https://ABC.gr7.us-west-2.eks.amazonaws.com", "abc.gr7.us-west-2.eks.amazonaws.com:443"
+// and "https://abc.gr7.us-west-2.eks.amazonaws.com/" are the same host. It
+// returns "" for an empty or unparsable address.
+func ServerHost(server string) string {
+	if server == "" {
+		return ""
+	}
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != "443" {
+		return net.JoinHostPort(host, port)
+	}
+	return host
+}
+
+func loadFile(path string) (*rest.Config, string, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", path)
+	if err != nil {
+		return nil, "", err
+	}
+	kubeconfig, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return config, kubeconfig.CurrentContext, nil
+}
+
+// inClusterName derives a cluster name from the pod's namespace, since
+// there is no kubeconfig context to name the cluster after.
+func inClusterName() string {
+	if namespace := Namespace(); namespace != "" {
+		return namespace
+	}
+	return "unknown-cluster"
+}
+
+// Context is a kubeconfig context resolved to a client configuration.
+type Context struct {
+	Name   string
+	Config *rest.Config
+}
+
+// ContextFlags select the kubeconfig contexts a sync runs against.
+type ContextFlags struct {
+	Contexts    []string
+	All         bool
+	Concurrency int
+}
+
+// AddFlags registers --context, --all-contexts and --context-concurrency.
+func (f *ContextFlags) AddFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&f.Contexts, "context", nil, "Sync this kubeconfig context (repeatable; default is the current context)")
+	flags.BoolVar(&f.All, "all-contexts", false, "Sync every context in the kubeconfig")
+	flags.IntVar(&f.Concurrency, "context-concurrency", 8, "Maximum number of contexts synced at the same time")
+}
+
+// Multi reports whether more than the current context was selected.
+func (f ContextFlags) Multi() bool {
+	return f.All || len(f.Contexts) > 0
+}
+
+// LoadContexts resolves the selected contexts of the kubeconfig file.
+func (f ContextFlags) LoadContexts() ([]Context, error) {
+	path := Path()
+	if path == "" {
+		return nil, fmt.Errorf("--context and --all-contexts need a kubeconfig file")
+	}
+	raw, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := f.Contexts
+	if f.All {
+		names = slices.Sorted(maps.Keys(raw.Contexts))
+	}
+	contexts := make([]Context, 0, len(names))
+	for _, name := range names {
+		if _, ok := raw.Contexts[name]; !ok {
+			return nil, fmt.Errorf("context %q not found in %s", name, path)
+		}
+		config, err := clientcmd.NewNonInteractiveClientConfig(*raw, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("context %q: %w", name, err)
+		}
+		contexts = append(contexts, Context{Name: name, Config: config})
+	}
+	return contexts, nil
+}
+
+// ForEach runs run for every context, at most concurrency at a time. A
+// failing context does not stop the others; their errors are joined.
+func ForEach(ctx context.Context, contexts []Context, concurrency int, run func(context.Context, Context) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	errs := make([]error, len(contexts))
+
+	var wg sync.WaitGroup
+	for i, c := range contexts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("context %s: %w", c.Name, ctx.Err())
+				return
+			}
+			defer func() { <-slots }()
+
+			if err := run(ctx, c); err != nil {
+				log.Error("Sync failed", "context", c.Name, "error", err)
+				errs[i] = fmt.Errorf("context %s: %w", c.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Expand replaces {context} in a per-cluster flag value, such as
+// --provider "k8s-{context}", with the context name.
+func Expand(value string, name string) string {
+	return strings.ReplaceAll(value, "{context}", name)
+}
@@ -0,0 +1,100 @@
+package kubeconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east.example.com
+- name: west
+  cluster:
+    server: https://west.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: prod-east
+  context: {cluster: east, user: admin}
+- name: prod-west
+  context: {cluster: west, user: admin}
+current-context: prod-east
+`
+
+func TestLoadContexts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", path)
+
+	contexts, err := ContextFlags{All: true}.LoadContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contexts) != 2 || contexts[0].Name != "prod-east" || contexts[1].Name != "prod-west" {
+		t.Fatalf("unexpected contexts: %+v", contexts)
+	}
+	if contexts[1].Config.Host != "https://west.example.com" {
+		t.Errorf("expected the west server for prod-west, got %s", contexts[1].Config.Host)
+	}
+
+	if _, err := (ContextFlags{Contexts: []string{"staging"}}).LoadContexts(); err == nil {
+		t.Error("expected an error for an unknown context")
+	}
+}
+
+func TestForEach_IsolatesFailures(t *testing.T) {
+	contexts := []Context{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	var synced []string
+	done := make(chan string, len(contexts))
+	err := ForEach(context.Background(), contexts, 1, func(_ context.Context, c Context) error {
+		if c.Name == "b" {
+			return errors.New("unreachable")
+		}
+		done <- c.Name
+		return nil
+	})
+	close(done)
+	for name := range done {
+		synced = append(synced, name)
+	}
+
+	if len(synced) != 2 {
+		t.Errorf("expected the other contexts to sync, got %v", synced)
+	}
+	if err == nil || !strings.Contains(err.Error(), "context b: unreachable") {
+		t.Errorf("expected the failure of b, got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	if got := Expand("k8s-{context}", "prod"); got != "k8s-prod" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestServerHost(t *testing.T) {
+	for server, want := range map[string]string{
+		"https://0123ABCD.gr7.us-west-2.eks.amazonaws.com":     "0123abcd.gr7.us-west-2.eks.amazonaws.com",
+		"https://34.118.2.10":                                  "34.118.2.10",
+		"34.118.2.10":                                          "34.118.2.10",
+		"https://prod-dns-1a2b.hcp.eastus.azmk8s.io:443":       "prod-dns-1a2b.hcp.eastus.azmk8s.io",
+		"https://kubernetes.default.svc/":                      "kubernetes.default.svc",
+		"https://rancher.example.com:8443/k8s/clusters/c-m-42": "rancher.example.com:8443",
+		"": "",
+	} {
+		if got := ServerHost(server); got != want {
+			t.Errorf("ServerHost(%q) = %q, want %q", server, got, want)
+		}
+	}
+}
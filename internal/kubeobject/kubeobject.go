@@ -0,0 +1,85 @@
+// Package kubeobject holds what the syncs of custom resources read through
+// the dynamic client, such as Argo CD and Flux, have in common: connecting to
+// the current kubeconfig context and the metadata every object carries.
+package kubeobject
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	ctrlp "github.com/ctrlplanedev/cli/internal/common"
+	"github.com/ctrlplanedev/cli/internal/kubeconfig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// FetchFunc lists the objects of a cluster and converts them to resources.
+type FetchFunc func(ctx context.Context, client dynamic.Interface, clusterName string) ([]api.ResourceProviderResource, error)
+
+// Sync fetches the resources of the current kubeconfig context and upserts
+// them. clusterName defaults to the kubeconfig cluster and providerName to
+// <prefix>-<cluster name>.
+func Sync(ctx context.Context, prefix, providerName, clusterName string, fetch FetchFunc, opts ...ctrlp.UpsertOption) error {
+	config, configClusterName, err := kubeconfig.Load()
+	if err != nil {
+		return err
+	}
+	if clusterName == "" {
+		clusterName = configClusterName
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	resources, err := fetch(ctx, client, clusterName)
+	if err != nil {
+		return err
+	}
+
+	if providerName == "" {
+		providerName = fmt.Sprintf("%s-%s", prefix, clusterName)
+	}
+	return ctrlp.UpsertResources(ctx, resources, &providerName, opts...)
+}
+
+// Metadata returns the labels of object as tags and its name, namespace and
+// cluster under prefix, e.g. argocd/name.
+func Metadata(object unstructured.Unstructured, prefix, clusterName string) map[string]string {
+	metadata := map[string]string{}
+	for key, value := range object.GetLabels() {
+		metadata[fmt.Sprintf("tags/%s", key)] = value
+	}
+	metadata[prefix+"/name"] = object.GetName()
+	metadata[prefix+"/namespace"] = object.GetNamespace()
+	metadata[prefix+"/cluster"] = clusterName
+	return metadata
+}
+
+// DropEmpty removes the keys without a value, so optional fields are absent
+// rather than empty.
+func DropEmpty(metadata map[string]string) {
+	for key, value := range metadata {
+		if value == "" {
+			delete(metadata, key)
+		}
+	}
+}
+
+// ShortRevision shortens a revision for display: a git commit SHA is cut to
+// 7 characters, as is the digest of a Flux revision such as
+// "main@sha1:4773b9f1...". Chart versions and branch names are kept.
+func ShortRevision(revision string) string {
+	if strings.Contains(revision, "@") {
+		if ref, digest, found := strings.Cut(revision, ":"); found && len(digest) > 7 {
+			return ref + ":" + digest[:7]
+		}
+		return revision
+	}
+	if len(revision) == 40 {
+		return revision[:7]
+	}
+	return revision
+}
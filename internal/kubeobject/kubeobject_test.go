@@ -0,0 +1,46 @@
+package kubeobject
+
+import (
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/kubeobject/kubeobjecttest"
+)
+
+func TestShortRevision(t *testing.T) {
+	for revision, want := range map[string]string{
+		"4773b9f1f02b5b6c5e0b5b2f1e5dbb6e7a3d1c2b":           "4773b9f",
+		"main@sha1:4773b9f1f02b5b6c5e0b5b2f1e5dbb6e7a3d1c2b": "main@sha1:4773b9f",
+		"6.5.4@sha256:0f3c9d2e4b":                            "6.5.4@sha256:0f3c9d2",
+		"6.5.4":                                              "6.5.4",
+		"HEAD":                                               "HEAD",
+		"":                                                   "",
+	} {
+		if got := ShortRevision(revision); got != want {
+			t.Errorf("ShortRevision(%q) = %q, want %q", revision, got, want)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	object := kubeobjecttest.NewObject("argoproj.io/v1alpha1", "Application", "argocd", "web", nil)
+	object.SetLabels(map[string]string{"team": "payments"})
+
+	metadata := Metadata(*object, "argocd", "prod")
+	metadata["argocd/empty"] = ""
+	DropEmpty(metadata)
+
+	want := map[string]string{
+		"tags/team":        "payments",
+		"argocd/name":      "web",
+		"argocd/namespace": "argocd",
+		"argocd/cluster":   "prod",
+	}
+	if len(metadata) != len(want) {
+		t.Errorf("metadata = %v, want %v", metadata, want)
+	}
+	for key, value := range want {
+		if metadata[key] != value {
+			t.Errorf("%s = %q, want %q", key, metadata[key], value)
+		}
+	}
+}
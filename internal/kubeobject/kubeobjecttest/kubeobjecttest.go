@@ -0,0 +1,23 @@
+// Package kubeobjecttest builds the unstructured objects the sync tests load
+// into fake dynamic clients.
+package kubeobjecttest
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NewObject returns an object with the given fields, such as spec and
+// status, and a UID derived from its name.
+func NewObject(apiVersion, kind, namespace, name string, fields map[string]any) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: fields}
+	if object.Object == nil {
+		object.Object = map[string]any{}
+	}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	object.SetUID(types.UID("uid-" + name))
+	return object
+}
@@ -0,0 +1,190 @@
+// Package notify posts the changes found by a sync run to a webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
+)
+
+// Change actions reported in an Event.
+const (
+	Added   = resourceprovider.ChangeAdded
+	Changed = resourceprovider.ChangeChanged
+	Removed = resourceprovider.ChangeRemoved
+)
+
+// Change is one resource that was added, changed or removed by a sync.
+type Change = resourceprovider.Change
+
+// Event is the payload posted to the webhook. Large diffs are split into
+// several events; Batch and Batches number them.
+type Event struct {
+	Provider  string    `json:"provider"`
+	Workspace string    `json:"workspace"`
+	Timestamp time.Time `json:"timestamp"`
+	Changes   []Change  `json:"changes"`
+	Batch     int       `json:"batch"`
+	Batches   int       `json:"batches"`
+}
+
+// Count returns the number of changes with the given action.
+func (e Event) Count(action string) int {
+	n := 0
+	for _, change := range e.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Text renders the event as a short Markdown message, as used by the Slack
+// template.
+func (e Event) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*: %d added, %d changed, %d removed", e.Provider, e.Count(Added), e.Count(Changed), e.Count(Removed))
+	if e.Batches > 1 {
+		fmt.Fprintf(&b, " (part %d/%d)", e.Batch, e.Batches)
+	}
+	for _, change := range e.Changes {
+		label := change.Identifier
+		if change.Name != "" && change.Name != change.Identifier {
+			label = fmt.Sprintf("%s (%s)", change.Name, change.Identifier)
+		}
+		fmt.Fprintf(&b, "\n• %s %s `%s`", change.Action, change.Kind, label)
+	}
+	return b.String()
+}
+
+// SlackTemplate produces a Slack incoming-webhook compatible payload.
+const SlackTemplate = `{"text": {{ json .Text }}}`
+
+// Notifier posts events to a webhook URL.
+type Notifier struct {
+	URL string
+	// Kinds limits notifications to changes of these resource kinds. Empty
+	// means all kinds.
+	Kinds []string
+	// BatchSize is the maximum number of changes per request.
+	BatchSize int
+	// Template renders the request body. Nil posts the event as JSON.
+	Template *template.Template
+	Client   *http.Client
+}
+
+// ParseTemplate returns the payload template for a --notify-template value:
+// "slack" for the built-in Slack template or a path to a Go template file.
+func ParseTemplate(value string) (*template.Template, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	text := SlackTemplate
+	if value != "slack" {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read notification template: %w", err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("notify").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notification template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Send posts the changes of the event, filtered by kind and split into
+// batches. Nothing is sent when no change passes the filter.
+func (n *Notifier) Send(ctx context.Context, event Event) error {
+	var changes []Change
+	for _, change := range event.Changes {
+		if len(n.Kinds) == 0 || slices.Contains(n.Kinds, change.Kind) {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	size := n.BatchSize
+	if size <= 0 {
+		size = len(changes)
+	}
+	batches := (len(changes) + size - 1) / size
+
+	for i := 0; i < batches; i++ {
+		batch := event
+		batch.Changes = changes[i*size : min((i+1)*size, len(changes))]
+		batch.Batch = i + 1
+		batch.Batches = batches
+		if err := n.post(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) post(ctx context.Context, event Event) error {
+	var body bytes.Buffer
+	if n.Template != nil {
+		if err := n.Template.Execute(&body, event); err != nil {
+			return fmt.Errorf("failed to render notification: %w", err)
+		}
+	} else if err := json.NewEncoder(&body).Encode(event); err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return retry.Do(
+		func() error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body.Bytes()))
+			if err != nil {
+				return retry.Unrecoverable(fmt.Errorf("failed to create notification request: %w", err))
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return fmt.Errorf("failed to send notification: %w", err)
+			}
+			defer resp.Body.Close()
+			io.Copy(io.Discard, resp.Body)
+
+			switch {
+			case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+				return fmt.Errorf("notification webhook returned %s", resp.Status)
+			case resp.StatusCode >= 400:
+				return retry.Unrecoverable(fmt.Errorf("notification webhook returned %s", resp.Status))
+			}
+			return nil
+		},
+		retry.Context(ctx),
+		retry.Attempts(5),
+		retry.Delay(500*time.Millisecond),
+		retry.MaxDelay(10*time.Second),
+		retry.DelayType(retry.BackOffDelay),
+	)
+}
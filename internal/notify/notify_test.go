@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testEvent() Event {
+	return Event{
+		Provider: "eks",
+		Changes: []Change{
+			{Action: Added, Identifier: "arn:cluster/a", Name: "a", Kind: "KubernetesCluster"},
+			{Action: Changed, Identifier: "arn:cluster/b", Name: "b", Kind: "KubernetesCluster"},
+			{Action: Removed, Identifier: "arn:db/c", Kind: "Database"},
+		},
+	}
+}
+
+func TestSend_BatchesFiltersAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var events []Event
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		events = append(events, event)
+	}))
+	defer server.Close()
+
+	notifier := Notifier{URL: server.URL, Kinds: []string{"KubernetesCluster"}, BatchSize: 1}
+	if err := notifier.Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(events))
+	}
+	for i, event := range events {
+		if len(event.Changes) != 1 || event.Changes[0].Kind != "KubernetesCluster" || event.Batch != i+1 || event.Batches != 2 {
+			t.Fatalf("unexpected batch %d: %+v", i, event)
+		}
+	}
+}
+
+func TestSend_SlackTemplate(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	tmpl, err := ParseTemplate("slack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier := Notifier{URL: server.URL, Template: tmpl}
+	if err := notifier.Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(payload["text"], "*eks*: 1 added, 1 changed, 1 removed") || !strings.Contains(payload["text"], "removed Database `arn:db/c`") {
+		t.Fatalf("unexpected Slack text: %q", payload["text"])
+	}
+}
+
+func TestSend_ClientErrorIsNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier := Notifier{URL: server.URL}
+	if err := notifier.Send(context.Background(), testEvent()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls)
+	}
+}
@@ -0,0 +1,127 @@
+// Package plugin discovers ctrlc plugins: executables on PATH named
+// "ctrlc-<name>", which become "ctrlc <name>", and "ctrlc-sync-<name>",
+// which become "ctrlc sync <name>".
+package plugin
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Prefix is the executable name prefix of top-level plugins.
+	Prefix = "ctrlc-"
+	// SyncPrefix is the executable name prefix of sync plugins.
+	SyncPrefix = "ctrlc-sync-"
+)
+
+// Plugin is an executable found on PATH.
+type Plugin struct {
+	// Name is the executable name without its prefix.
+	Name string
+	Path string
+}
+
+// Find returns the executables on PATH whose names start with prefix. When
+// several PATH entries hold the same name the first one wins, like in the
+// shell.
+func Find(prefix string) []Plugin {
+	seen := map[string]bool{}
+	var plugins []Plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), prefix)
+			if !ok || name == "" || seen[name] || entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0
+}
+
+// Env returns the environment for a plugin process: the current environment
+// plus the resolved API connection settings, so plugins see the same
+// configuration as ctrlc whether it came from flags, env or the config file.
+func Env() []string {
+	env := os.Environ()
+	for key, name := range map[string]string{
+		"url":       "CTRLPLANE_URL",
+		"api-key":   "CTRLPLANE_API_KEY",
+		"workspace": "CTRLPLANE_WORKSPACE",
+	} {
+		if value := viper.GetString(key); value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// AddCommands registers a command for every top-level plugin whose name is
+// not taken by a built-in command. Sync plugins are left to the sync
+// command.
+func AddCommands(root *cobra.Command) {
+	taken := map[string]bool{}
+	for _, cmd := range root.Commands() {
+		taken[cmd.Name()] = true
+		for _, alias := range cmd.Aliases {
+			taken[alias] = true
+		}
+	}
+
+	for _, p := range Find(Prefix) {
+		if taken[p.Name] || strings.HasPrefix(p.Name, "sync-") {
+			continue
+		}
+		root.AddCommand(newCommand(p))
+	}
+}
+
+// newCommand runs the plugin with all arguments passed through untouched.
+func newCommand(p Plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:                p.Name,
+		Short:              "Plugin " + filepath.Base(p.Path),
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			child := exec.CommandContext(cmd.Context(), p.Path, args...)
+			child.Env = Env()
+			child.Stdin = os.Stdin
+			child.Stdout = os.Stdout
+			child.Stderr = os.Stderr
+
+			err := child.Run()
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
+			return err
+		},
+	}
+}
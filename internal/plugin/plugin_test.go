@@ -0,0 +1,30 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name string, mode os.FileMode) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(first, "ctrlc-sync-cmdb", 0o755)
+	write(first, "ctrlc-sync-notes.txt", 0o644)
+	write(second, "ctrlc-sync-cmdb", 0o755)
+	write(second, "ctrlc-sync-lab", 0o755)
+	write(second, "ctrlc-hello", 0o755)
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+
+	plugins := Find(SyncPrefix)
+	if len(plugins) != 2 || plugins[0].Name != "cmdb" || plugins[1].Name != "lab" {
+		t.Fatalf("unexpected plugins: %+v", plugins)
+	}
+	if plugins[0].Path != filepath.Join(first, "ctrlc-sync-cmdb") {
+		t.Fatalf("expected the first PATH entry to win, got %s", plugins[0].Path)
+	}
+}
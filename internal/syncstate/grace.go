@@ -0,0 +1,121 @@
+package syncstate
+
+import (
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+// MissingSinceKey is the metadata key set on resources that are kept during
+// their grace period after disappearing from a sync.
+const MissingSinceKey = "ctrlplane/missing-since"
+
+// GracePolicy controls how long resources that disappear from a sync are
+// kept before they are removed. A zero policy removes them immediately.
+type GracePolicy struct {
+	// Period keeps a missing resource for this long after it was first
+	// missed.
+	Period time.Duration
+	// Runs keeps a missing resource for this many syncs.
+	Runs int
+}
+
+// Enabled reports whether missing resources are kept at all.
+func (p GracePolicy) Enabled() bool {
+	return p.Period > 0 || p.Runs > 0
+}
+
+// expired reports whether a resource missed since the given time and for the
+// given number of runs has outlived the policy. When both limits are set the
+// resource is kept until it has exceeded both.
+func (p GracePolicy) expired(since time.Time, runs int, now time.Time) bool {
+	periodOver := p.Period <= 0 || now.Sub(since) > p.Period
+	runsOver := p.Runs <= 0 || runs > p.Runs
+	return periodOver && runsOver
+}
+
+// Snapshot is the last version of a resource sent by a sync, kept so the
+// resource can be re-sent while it is missing.
+type Snapshot struct {
+	Resource  api.ResourceProviderResource `json:"resource"`
+	Variables map[string]any               `json:"variables,omitempty"`
+	// MissingSince is set once the resource stops appearing in syncs.
+	MissingSince *time.Time `json:"missingSince,omitempty"`
+	// MissedRuns counts the consecutive syncs the resource was missing from.
+	MissedRuns int `json:"missedRuns,omitempty"`
+}
+
+// Retained is the outcome of applying a grace policy to a sync.
+type Retained struct {
+	// Resources are the synced resources followed by the missing resources
+	// still within their grace period, marked with MissingSinceKey.
+	Resources []api.ResourceProviderResource
+	// Variables holds the variables of all returned resources.
+	Variables map[string]map[string]any
+	// Snapshots should be stored with the new state.
+	Snapshots map[string]Snapshot
+	// Missing and Expired list the identifiers kept and dropped this run.
+	Missing []string
+	Expired []string
+}
+
+// Retain adds the resources of the previous sync that are missing from this
+// one back into the set until the policy's grace period runs out.
+func Retain(previous *State, resources []api.ResourceProviderResource, variables map[string]map[string]any, policy GracePolicy, now time.Time) Retained {
+	result := Retained{
+		Resources: append([]api.ResourceProviderResource(nil), resources...),
+		Variables: make(map[string]map[string]any, len(variables)),
+		Snapshots: make(map[string]Snapshot, len(resources)),
+	}
+	maps.Copy(result.Variables, variables)
+
+	for _, resource := range resources {
+		result.Snapshots[resource.Identifier] = Snapshot{
+			Resource:  resource,
+			Variables: variables[resource.Identifier],
+		}
+	}
+
+	var before map[string]Snapshot
+	if previous != nil {
+		before = previous.Snapshots
+	}
+	identifiers := make([]string, 0, len(before))
+	for identifier := range before {
+		if _, ok := result.Snapshots[identifier]; !ok {
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	sort.Strings(identifiers)
+
+	for _, identifier := range identifiers {
+		snapshot := before[identifier]
+		if snapshot.MissingSince == nil {
+			since := now.UTC()
+			snapshot.MissingSince = &since
+		}
+		snapshot.MissedRuns++
+
+		if policy.expired(*snapshot.MissingSince, snapshot.MissedRuns, now) {
+			result.Expired = append(result.Expired, identifier)
+			continue
+		}
+
+		resource := snapshot.Resource
+		resource.Metadata = maps.Clone(resource.Metadata)
+		if resource.Metadata == nil {
+			resource.Metadata = map[string]string{}
+		}
+		resource.Metadata[MissingSinceKey] = snapshot.MissingSince.Format(time.RFC3339)
+
+		result.Resources = append(result.Resources, resource)
+		if snapshot.Variables != nil {
+			result.Variables[identifier] = snapshot.Variables
+		}
+		result.Snapshots[identifier] = snapshot
+		result.Missing = append(result.Missing, identifier)
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+// Package syncstate remembers what a sync last sent for a resource provider,
+// so repeated syncs can tell whether anything changed.
+package syncstate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+// State is the persisted record of the last successful sync of one provider.
+type State struct {
+	// Hash covers the full canonicalized resource set.
+	Hash string `json:"hash"`
+	// Resources maps each resource identifier to its own hash.
+	Resources map[string]string `json:"resources"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	// Kinds maps each resource identifier to its kind, so removed resources
+	// can still be described.
+	Kinds map[string]string `json:"kinds,omitempty"`
+	// Snapshots holds the last sent version of each resource. It is only
+	// recorded when a grace period is configured.
+	Snapshots map[string]Snapshot `json:"snapshots,omitempty"`
+}
+
+// Store reads and writes state files in a directory, one per workspace and
+// provider.
+type Store struct {
+	Dir string
+}
+
+// DefaultDir returns the directory used when no state directory is
+// configured.
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ctrlc", "sync-state")
+}
+
+// Load returns the stored state for the provider, or nil when none exists.
+func (s Store) Load(workspace, provider string) (*State, error) {
+	data, err := os.ReadFile(s.path(workspace, provider))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return &state, nil
+}
+
+// Save writes the state for the provider atomically.
+func (s Store) Save(workspace, provider string, state *State) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create sync state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+
+	path := s.path(workspace, provider)
+	tmp, err := os.CreateTemp(s.Dir, filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+func (s Store) path(workspace, provider string) string {
+	return filepath.Join(s.Dir, sanitize(workspace)+"_"+sanitize(provider)+".json")
+}
+
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// Compute hashes each resource, together with its variables, and the
+// resource set as a whole. The result does not depend on the order of
+// resources or of map keys.
+func Compute(resources []api.ResourceProviderResource, variables map[string]map[string]any) (*State, error) {
+	hashes := make(map[string]string, len(resources))
+	kinds := make(map[string]string, len(resources))
+	for _, resource := range resources {
+		kinds[resource.Identifier] = resource.Kind
+		// encoding/json sorts map keys, which makes the encoding canonical.
+		data, err := json.Marshal(struct {
+			Resource  api.ResourceProviderResource `json:"resource"`
+			Variables map[string]any               `json:"variables,omitempty"`
+		}{resource, variables[resource.Identifier]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode resource %q: %w", resource.Identifier, err)
+		}
+		sum := sha256.Sum256(data)
+		hashes[resource.Identifier] = hex.EncodeToString(sum[:])
+	}
+
+	identifiers := make([]string, 0, len(hashes))
+	for identifier := range hashes {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	set := sha256.New()
+	for _, identifier := range identifiers {
+		fmt.Fprintf(set, "%s\x00%s\n", identifier, hashes[identifier])
+	}
+
+	return &State{
+		Hash:      hex.EncodeToString(set.Sum(nil)),
+		Resources: hashes,
+		UpdatedAt: time.Now().UTC(),
+		Kinds:     kinds,
+	}, nil
+}
+
+// Diff lists the identifiers added, changed and removed between the previous
+// state and the current one. A nil previous state reports everything as
+// added.
+func Diff(previous, current *State) (added, changed, removed []string) {
+	var before map[string]string
+	if previous != nil {
+		before = previous.Resources
+	}
+
+	for identifier, hash := range current.Resources {
+		old, ok := before[identifier]
+		switch {
+		case !ok:
+			added = append(added, identifier)
+		case old != hash:
+			changed = append(changed, identifier)
+		}
+	}
+	for identifier := range before {
+		if _, ok := current.Resources[identifier]; !ok {
+			removed = append(removed, identifier)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return added, changed, removed
+}
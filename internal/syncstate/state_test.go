@@ -0,0 +1,101 @@
+package syncstate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+func TestCompute_OrderIndependent(t *testing.T) {
+	a := api.ResourceProviderResource{
+		Identifier: "a",
+		Metadata:   map[string]string{"x": "1", "y": "2"},
+		Config:     map[string]interface{}{"nested": map[string]interface{}{"b": 1, "a": 2}},
+	}
+	b := api.ResourceProviderResource{Identifier: "b", Metadata: map[string]string{}}
+
+	first, err := Compute([]api.ResourceProviderResource{a, b}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Compute([]api.ResourceProviderResource{b, a}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Hash != second.Hash {
+		t.Fatalf("hash depends on resource order: %s != %s", first.Hash, second.Hash)
+	}
+
+	b.Metadata["z"] = "3"
+	third, err := Compute([]api.ResourceProviderResource{a, b}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Hash == first.Hash {
+		t.Fatal("hash did not change when a resource changed")
+	}
+
+	added, changed, removed := Diff(first, third)
+	if len(added) != 0 || len(removed) != 0 || len(changed) != 1 || changed[0] != "b" {
+		t.Fatalf("unexpected diff: added=%v changed=%v removed=%v", added, changed, removed)
+	}
+}
+
+func TestStore_RoundTrip(t *testing.T) {
+	store := Store{Dir: t.TempDir()}
+
+	state, err := store.Load("workspace", "provider/name")
+	if err != nil || state != nil {
+		t.Fatalf("expected no state, got %v, %v", state, err)
+	}
+
+	saved, err := Compute([]api.ResourceProviderResource{{Identifier: "a"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("workspace", "provider/name", saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load("workspace", "provider/name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || loaded.Hash != saved.Hash || loaded.Resources["a"] != saved.Resources["a"] {
+		t.Fatalf("loaded state %+v does not match saved %+v", loaded, saved)
+	}
+}
+
+func TestRetain_GracePeriod(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := api.ResourceProviderResource{Identifier: "a", Metadata: map[string]string{"x": "1"}}
+	b := api.ResourceProviderResource{Identifier: "b"}
+	policy := GracePolicy{Period: time.Hour, Runs: 2}
+
+	state := &State{Snapshots: Retain(nil, []api.ResourceProviderResource{a, b}, nil, policy, start).Snapshots}
+
+	// b disappears: it is kept and marked for as long as either limit holds.
+	for run, now := range []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(30 * time.Minute)} {
+		retained := Retain(state, []api.ResourceProviderResource{a}, nil, policy, now)
+		if len(retained.Resources) != 2 || len(retained.Missing) != 1 {
+			t.Fatalf("run %d: expected b to be kept, got %+v", run, retained)
+		}
+		kept := retained.Resources[1]
+		if kept.Metadata[MissingSinceKey] != start.Add(time.Minute).Format(time.RFC3339) {
+			t.Fatalf("run %d: unexpected marker %#v", run, kept.Metadata)
+		}
+		state = &State{Snapshots: retained.Snapshots}
+	}
+
+	retained := Retain(state, []api.ResourceProviderResource{a}, nil, policy, start.Add(2*time.Hour))
+	if len(retained.Resources) != 1 || len(retained.Expired) != 1 || retained.Expired[0] != "b" {
+		t.Fatalf("expected b to expire, got %+v", retained)
+	}
+
+	// A resource that comes back loses its marker.
+	retained = Retain(state, []api.ResourceProviderResource{a, b}, nil, policy, start.Add(40*time.Minute))
+	if len(retained.Missing) != 0 || retained.Snapshots["b"].MissingSince != nil {
+		t.Fatalf("expected b to be live again, got %+v", retained.Snapshots["b"])
+	}
+}
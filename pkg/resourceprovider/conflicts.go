@@ -0,0 +1,142 @@
+package resourceprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+// ConflictPolicy decides what happens to a resource whose identifier is
+// already owned by a different provider.
+type ConflictPolicy string
+
+const (
+	// ConflictTakeOver upserts the resource anyway, moving it to this
+	// provider. A warning is logged for every conflict.
+	ConflictTakeOver ConflictPolicy = "take-over"
+	// ConflictSkip leaves the resource with its current owner and drops it
+	// from this sync.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictFail aborts the sync before anything is upserted.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ParseConflictPolicy validates an --on-conflict value. An empty value is
+// the take-over default.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(value); policy {
+	case "":
+		return ConflictTakeOver, nil
+	case ConflictTakeOver, ConflictSkip, ConflictFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy %q (expected skip, fail or take-over)", value)
+	}
+}
+
+// Conflict is an identifier claimed by another provider.
+type Conflict struct {
+	Identifier string
+	ProviderID string
+}
+
+// searchBatchSize bounds the number of identifiers sent per search request.
+const searchBatchSize = 200
+
+// FindConflicts returns the identifiers that already belong to a provider
+// other than providerId. Resources without a provider are not conflicts.
+func FindConflicts(ctx context.Context, client *api.ClientWithResponses, workspaceId string, providerId string, identifiers []string) ([]Conflict, error) {
+	var conflicts []Conflict
+	for start := 0; start < len(identifiers); start += searchBatchSize {
+		batch := identifiers[start:min(start+searchBatchSize, len(identifiers))]
+
+		for offset := 0; ; {
+			limit := len(batch)
+			resp, err := client.SearchResourcesWithResponse(ctx, workspaceId, api.SearchResourcesJSONRequestBody{
+				Identifiers: &batch,
+				Limit:       &limit,
+				Offset:      &offset,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to search resources: %w", err)
+			}
+			if resp.JSON200 == nil {
+				return nil, fmt.Errorf("failed to search resources: %s", resp.Status())
+			}
+
+			for _, resource := range resp.JSON200.Items {
+				if resource.ProviderId == nil || *resource.ProviderId == "" || *resource.ProviderId == providerId {
+					continue
+				}
+				conflicts = append(conflicts, Conflict{Identifier: resource.Identifier, ProviderID: *resource.ProviderId})
+			}
+
+			offset += len(resp.JSON200.Items)
+			if len(resp.JSON200.Items) == 0 || offset >= resp.JSON200.Total {
+				break
+			}
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Identifier < conflicts[j].Identifier })
+	return conflicts, nil
+}
+
+// ResolveConflicts applies the policy to the conflicts found for the
+// resources of the named provider and returns the resources to upsert.
+func ResolveConflicts(provider string, resources []api.ResourceProviderResource, conflicts []Conflict, policy ConflictPolicy) ([]api.ResourceProviderResource, error) {
+	if len(conflicts) == 0 {
+		return resources, nil
+	}
+
+	owners := make(map[string]string, len(conflicts))
+	for _, conflict := range conflicts {
+		owners[conflict.Identifier] = conflict.ProviderID
+	}
+
+	switch policy {
+	case ConflictFail:
+		described := make([]string, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			described = append(described, fmt.Sprintf("%s (provider %s)", conflict.Identifier, conflict.ProviderID))
+		}
+		return nil, fmt.Errorf("%d resource(s) of provider %q are owned by another provider: %s; rerun with --on-conflict=skip or --on-conflict=take-over",
+			len(conflicts), provider, strings.Join(described, ", "))
+
+	case ConflictSkip:
+		kept := make([]api.ResourceProviderResource, 0, len(resources))
+		for _, resource := range resources {
+			if owner, ok := owners[resource.Identifier]; ok {
+				log.Warn("Skipping resource owned by another provider", "provider", provider, "identifier", resource.Identifier, "owner", owner)
+				continue
+			}
+			kept = append(kept, resource)
+		}
+		return kept, nil
+
+	default:
+		for _, conflict := range conflicts {
+			log.Warn("Taking over resource owned by another provider", "provider", provider, "identifier", conflict.Identifier, "owner", conflict.ProviderID)
+		}
+		return resources, nil
+	}
+}
+
+// CheckConflicts finds the resources already owned by other providers and
+// applies the policy to them.
+func (r *ResourceProvider) CheckConflicts(ctx context.Context, resources []api.ResourceProviderResource, policy ConflictPolicy) ([]api.ResourceProviderResource, error) {
+	identifiers := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		identifiers = append(identifiers, resource.Identifier)
+	}
+
+	conflicts, err := FindConflicts(ctx, r.client, r.workspaceId, r.ID, identifiers)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveConflicts(r.Name, resources, conflicts, policy)
+}
@@ -0,0 +1,196 @@
+package resourceprovider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/api/apitest"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		value   string
+		want    ConflictPolicy
+		wantErr bool
+	}{
+		{value: "", want: ConflictTakeOver},
+		{value: "take-over", want: ConflictTakeOver},
+		{value: "skip", want: ConflictSkip},
+		{value: "fail", want: ConflictFail},
+		{value: "overwrite", wantErr: true},
+	} {
+		got, err := ParseConflictPolicy(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseConflictPolicy(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseConflictPolicy(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	mine := apitest.ProviderID("mine")
+	other := apitest.ProviderID("other")
+
+	// 450 identifiers take three search batches. Every tenth belongs to
+	// another provider; the rest are ours, unowned or unknown.
+	identifiers := make([]string, 450)
+	var want []Conflict
+	owners := map[string]string{}
+	for i := range identifiers {
+		identifiers[i] = fmt.Sprintf("r-%03d", i)
+		switch i % 10 {
+		case 0:
+			owners[identifiers[i]] = other
+			want = append(want, Conflict{Identifier: identifiers[i], ProviderID: other})
+		case 1:
+			owners[identifiers[i]] = mine
+		case 2:
+			owners[identifiers[i]] = ""
+		}
+	}
+
+	for _, tt := range []struct {
+		name         string
+		pageSize     int
+		wantSearches int
+	}{
+		{name: "one page per batch", wantSearches: 3},
+		// The batches hold 60, 60 and 15 known resources.
+		{name: "paginated", pageSize: 7, wantSearches: 9 + 9 + 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := apitest.NewServer(t)
+			server.PageSize = tt.pageSize
+			server.Owners = owners
+
+			conflicts, err := FindConflicts(context.Background(), server.Client, apitest.WorkspaceID, mine, identifiers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(conflicts, want) {
+				t.Errorf("conflicts = %v, want %v", conflicts, want)
+			}
+			if len(server.Searches) != tt.wantSearches {
+				t.Errorf("searches = %d, want %d", len(server.Searches), tt.wantSearches)
+			}
+			for _, search := range server.Searches {
+				if len(search) > searchBatchSize {
+					t.Errorf("search of %d identifiers exceeds the batch size", len(search))
+				}
+			}
+		})
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	resources := []api.ResourceProviderResource{{Identifier: "a"}, {Identifier: "b"}, {Identifier: "c"}}
+	conflicts := []Conflict{{Identifier: "b", ProviderID: "provider-other"}}
+
+	for _, tt := range []struct {
+		name      string
+		conflicts []Conflict
+		policy    ConflictPolicy
+		want      []string
+		wantErr   string
+	}{
+		{name: "no conflicts", policy: ConflictFail, want: []string{"a", "b", "c"}},
+		{name: "take over", conflicts: conflicts, policy: ConflictTakeOver, want: []string{"a", "b", "c"}},
+		{name: "skip", conflicts: conflicts, policy: ConflictSkip, want: []string{"a", "c"}},
+		{name: "fail", conflicts: conflicts, policy: ConflictFail, wantErr: "b (provider provider-other)"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, err := ResolveConflicts("mine", resources, tt.conflicts, tt.policy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := identifiersOf(kept); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSync_SkippedConflictStaysPending(t *testing.T) {
+	ctx := context.Background()
+	server := apitest.NewServer(t)
+	server.Owners["b"] = apitest.ProviderID("other")
+
+	rp, err := New(server.Client, apitest.WorkspaceID, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := []Resource{
+		{Identifier: "a", Name: "a", Kind: "Server", Version: "v1"},
+		{Identifier: "b", Name: "b", Kind: "Server", Version: "v1"},
+	}
+	opts := []SyncOption{
+		WithStateDir(t.TempDir()),
+		WithConflictPolicy(ConflictSkip),
+		WithVariables(map[string]map[string]any{"a": {"env": "prod"}, "b": {"env": "prod"}}),
+	}
+
+	result, err := rp.Sync(ctx, resources, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := server.Sets[rp.ID]
+	if got := identifiersOf(sets[len(sets)-1]); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("first sync sent %v, want [a]", got)
+	}
+	if want := []Change{{Action: ChangeAdded, Identifier: "a", Name: "a", Kind: "Server"}}; !reflect.DeepEqual(result.Changes, want) {
+		t.Errorf("first sync changes = %v, want %v", result.Changes, want)
+	}
+	if _, ok := server.Variables["b"]; ok {
+		t.Error("expected no variables for the skipped resource")
+	}
+
+	// Once the other provider lets go, the skipped resource is sent even
+	// though the input did not change.
+	delete(server.Owners, "b")
+	result, err = rp.Sync(ctx, resources, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Unchanged {
+		t.Fatal("expected the skipped resource to be retried")
+	}
+	sets = server.Sets[rp.ID]
+	if got := identifiersOf(sets[len(sets)-1]); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("second sync sent %v, want [a b]", got)
+	}
+	if want := []Change{{Action: ChangeAdded, Identifier: "b", Name: "b", Kind: "Server"}}; !reflect.DeepEqual(result.Changes, want) {
+		t.Errorf("second sync changes = %v, want %v", result.Changes, want)
+	}
+	if _, ok := server.Variables["b"]; !ok {
+		t.Error("expected the variables of the retried resource to be sent")
+	}
+
+	result, err = rp.Sync(ctx, resources, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Unchanged {
+		t.Error("expected the third sync to be unchanged")
+	}
+}
+
+func identifiersOf(resources []api.ResourceProviderResource) []string {
+	identifiers := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		identifiers = append(identifiers, resource.Identifier)
+	}
+	return identifiers
+}
@@ -0,0 +1,13 @@
+// Package resourceprovider is the SDK for writing Ctrlplane resource
+// providers in Go. It is what the built-in `ctrlc sync` integrations use, so
+// a custom provider gets the same behavior:
+//
+//   - ResourceBuilder and RuleBuilder assemble resources, with helpers for the
+//     standard kubernetes/*, database/* and cloud metadata and for links, and
+//     relationship rules.
+//   - ResourceProvider.Sync sends the whole resource set in one request,
+//     retrying transient failures, skips unchanged sets, uploads variables
+//     for new and changed resources, and reports what changed.
+//   - Options add grace periods for disappearing resources, identifier
+//     conflict handling and relationship rules.
+package resourceprovider
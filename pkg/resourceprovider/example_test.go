@@ -0,0 +1,68 @@
+package resourceprovider_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ctrlplanedev/cli/pkg/resourceprovider"
+)
+
+type server struct {
+	ID, Hostname, Rack, Region, OSVersion string
+}
+
+func listServers() []server {
+	return []server{{ID: "42", Hostname: "web-1", Rack: "r1", Region: "eu-west-1", OSVersion: "22.04.3"}}
+}
+
+func Example() {
+	ctx := context.Background()
+
+	client, err := resourceprovider.NewClient(os.Getenv("CTRLPLANE_URL"), os.Getenv("CTRLPLANE_API_KEY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	provider, err := resourceprovider.New(client, os.Getenv("CTRLPLANE_WORKSPACE"), "cmdb")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var resources []resourceprovider.Resource
+	variables := map[string]map[string]any{}
+	for _, s := range listServers() {
+		resource, err := resourceprovider.NewResource("cmdb/"+s.ID, s.Hostname, "Server", "cmdb/v1").
+			Config("hostname", s.Hostname).
+			Metadata("cmdb/rack", s.Rack).
+			Metadata("cmdb/region", s.Region).
+			SemanticVersion("os/version", s.OSVersion).
+			Link("CMDB", "https://cmdb.internal/servers/"+s.ID).
+			Build()
+		if err != nil {
+			log.Fatal(err)
+		}
+		resources = append(resources, resource)
+		variables[resource.Identifier] = map[string]any{"ssh_host": s.Hostname}
+	}
+
+	regionRule := resourceprovider.NewRelationshipRule("CMDB server region", "region").
+		From("Server", "cmdb/v1").
+		To("Region", "").
+		MatchMetadata("cmdb/region", "region/name").
+		Build()
+
+	result, err := provider.Sync(ctx, resources,
+		resourceprovider.WithVariables(variables),
+		resourceprovider.WithRelationshipRules(regionRule),
+		resourceprovider.WithGracePeriod(30*time.Minute, 0),
+		resourceprovider.WithConflictPolicy(resourceprovider.ConflictFail),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, change := range result.Changes {
+		fmt.Println(change.Action, change.Identifier)
+	}
+}
@@ -0,0 +1,259 @@
+package resourceprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/ctrlplanedev/cli/internal/kinds"
+)
+
+// Client is the Ctrlplane API client used by resource providers.
+type Client = api.ClientWithResponses
+
+// Resource is a resource as sent by a provider.
+type Resource = api.ResourceProviderResource
+
+// RelationshipRule declares how resources relate to each other.
+type RelationshipRule = api.UpsertRelationshipRuleRequest
+
+// NewClient returns an API client authenticated with an API key.
+func NewClient(url string, apiKey string) (*Client, error) {
+	return api.NewAPIKeyClientWithResponses(url, apiKey)
+}
+
+// ResourceBuilder assembles a Resource. Setters return the builder so calls
+// can be chained; Build returns the result.
+type ResourceBuilder struct {
+	resource Resource
+	links    map[string]string
+}
+
+// NewResource starts a resource with its required fields.
+func NewResource(identifier, name, kind, version string) *ResourceBuilder {
+	return &ResourceBuilder{
+		resource: Resource{
+			Identifier: identifier,
+			Name:       name,
+			Kind:       kind,
+			Version:    version,
+			Config:     map[string]any{},
+			Metadata:   map[string]string{},
+		},
+		links: map[string]string{},
+	}
+}
+
+// Config sets one config value.
+func (b *ResourceBuilder) Config(key string, value any) *ResourceBuilder {
+	b.resource.Config[key] = value
+	return b
+}
+
+// MergeConfig copies all values into the config.
+func (b *ResourceBuilder) MergeConfig(config map[string]any) *ResourceBuilder {
+	maps.Copy(b.resource.Config, config)
+	return b
+}
+
+// Metadata sets one metadata value. Empty values are skipped.
+func (b *ResourceBuilder) Metadata(key, value string) *ResourceBuilder {
+	if value != "" {
+		b.resource.Metadata[key] = value
+	}
+	return b
+}
+
+// MergeMetadata copies all values into the metadata.
+func (b *ResourceBuilder) MergeMetadata(metadata map[string]string) *ResourceBuilder {
+	for key, value := range metadata {
+		b.Metadata(key, value)
+	}
+	return b
+}
+
+// Link adds a named link shown with the resource in the UI.
+func (b *ResourceBuilder) Link(name, url string) *ResourceBuilder {
+	b.links[name] = url
+	return b
+}
+
+// SemanticVersion sets key to the version and key/major, key/minor,
+// key/patch and key/prerelease to its parts. Versions that do not parse as
+// semver only set key.
+func (b *ResourceBuilder) SemanticVersion(key, version string) *ResourceBuilder {
+	b.Metadata(key, version)
+	parsed, err := semver.NewVersion(version)
+	if err != nil {
+		return b
+	}
+	b.Metadata(key+"/major", strconv.FormatInt(parsed.Major(), 10))
+	b.Metadata(key+"/minor", strconv.FormatInt(parsed.Minor(), 10))
+	b.Metadata(key+"/patch", strconv.FormatInt(parsed.Patch(), 10))
+	b.Metadata(key+"/prerelease", parsed.Prerelease())
+	return b
+}
+
+// KubernetesMetadata holds the standard metadata of Kubernetes resources.
+type KubernetesMetadata struct {
+	Type        string
+	Name        string
+	Namespace   string
+	ClusterName string
+	UID         string
+	Version     string
+	Status      string
+}
+
+// Kubernetes sets the kubernetes/* metadata used by the built-in syncs.
+func (b *ResourceBuilder) Kubernetes(m KubernetesMetadata) *ResourceBuilder {
+	b.Metadata(kinds.K8SMetadataType, m.Type)
+	b.Metadata(kinds.K8SMetadataName, m.Name)
+	b.Metadata(kinds.K8SMetadataNamespace, m.Namespace)
+	b.Metadata(kinds.K8SMetadataClusterName, m.ClusterName)
+	b.Metadata(kinds.K8SMetadataUID, m.UID)
+	b.Metadata(kinds.K8SMetadataStatus, m.Status)
+	if m.Version != "" {
+		b.SemanticVersion(kinds.K8SMetadataVersion, m.Version)
+	}
+	return b
+}
+
+// DatabaseMetadata holds the standard metadata of database resources.
+type DatabaseMetadata struct {
+	Type    string
+	Name    string
+	Host    string
+	Port    int
+	Version string
+	Region  string
+	State   string
+	SSL     *bool
+}
+
+// Database sets the database/* metadata used by the built-in syncs.
+func (b *ResourceBuilder) Database(m DatabaseMetadata) *ResourceBuilder {
+	b.Metadata(kinds.DBMetadataType, m.Type)
+	b.Metadata(kinds.DBMetadataName, m.Name)
+	b.Metadata(kinds.DBMetadataHost, m.Host)
+	if m.Port != 0 {
+		b.Metadata(kinds.DBMetadataPort, strconv.Itoa(m.Port))
+	}
+	b.Metadata(kinds.DBMetadataRegion, m.Region)
+	b.Metadata(kinds.DBMetadataState, m.State)
+	if m.SSL != nil {
+		b.Metadata(kinds.DBMetadataSSL, strconv.FormatBool(*m.SSL))
+	}
+	if m.Version != "" {
+		b.SemanticVersion(kinds.DBMetadataVersion, m.Version)
+	}
+	return b
+}
+
+// AWSARN sets the aws/arn metadata.
+func (b *ResourceBuilder) AWSARN(arn string) *ResourceBuilder {
+	return b.Metadata(kinds.AWSMetadataARN, arn)
+}
+
+// GoogleSelfLink sets the google/self-link metadata.
+func (b *ResourceBuilder) GoogleSelfLink(link string) *ResourceBuilder {
+	return b.Metadata(kinds.GoogleMetadataSelfLink, link)
+}
+
+// AzureID sets the azure/id metadata.
+func (b *ResourceBuilder) AzureID(id string) *ResourceBuilder {
+	return b.Metadata(kinds.AzureMetadataId, id)
+}
+
+// Build validates and returns the resource.
+func (b *ResourceBuilder) Build() (Resource, error) {
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"identifier", b.resource.Identifier},
+		{"name", b.resource.Name},
+		{"kind", b.resource.Kind},
+		{"version", b.resource.Version},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return Resource{}, fmt.Errorf("resource %q is missing %s", b.resource.Identifier, strings.Join(missing, ", "))
+	}
+
+	resource := b.resource
+	resource.Config = maps.Clone(b.resource.Config)
+	resource.Metadata = maps.Clone(b.resource.Metadata)
+	if len(b.links) > 0 {
+		links, err := json.Marshal(b.links)
+		if err != nil {
+			return Resource{}, fmt.Errorf("failed to encode links: %w", err)
+		}
+		resource.Metadata[kinds.CtrlplaneMetadataLinks] = string(links)
+	}
+	return resource, nil
+}
+
+// RuleBuilder assembles a RelationshipRule from resource selectors and
+// matching metadata keys.
+type RuleBuilder struct {
+	name       string
+	reference  string
+	conditions []string
+}
+
+// NewRelationshipRule starts a rule. The reference names the relationship
+// as seen from the source resource.
+func NewRelationshipRule(name, reference string) *RuleBuilder {
+	return &RuleBuilder{name: name, reference: reference}
+}
+
+// From restricts the source resources to a kind and version. Empty values
+// match any.
+func (b *RuleBuilder) From(kind, version string) *RuleBuilder {
+	return b.selector("from", kind, version)
+}
+
+// To restricts the target resources to a kind and version. Empty values
+// match any.
+func (b *RuleBuilder) To(kind, version string) *RuleBuilder {
+	return b.selector("to", kind, version)
+}
+
+func (b *RuleBuilder) selector(side, kind, version string) *RuleBuilder {
+	if kind != "" {
+		b.conditions = append(b.conditions, fmt.Sprintf("%s.kind == %s", side, strconv.Quote(kind)))
+	}
+	if version != "" {
+		b.conditions = append(b.conditions, fmt.Sprintf("%s.version == %s", side, strconv.Quote(version)))
+	}
+	return b
+}
+
+// MatchMetadata requires the source's fromKey metadata to equal the
+// target's toKey metadata.
+func (b *RuleBuilder) MatchMetadata(fromKey, toKey string) *RuleBuilder {
+	b.conditions = append(b.conditions, fmt.Sprintf("from.metadata[%s] == to.metadata[%s]", strconv.Quote(fromKey), strconv.Quote(toKey)))
+	return b
+}
+
+// Where adds a raw CEL condition over from and to.
+func (b *RuleBuilder) Where(cel string) *RuleBuilder {
+	b.conditions = append(b.conditions, cel)
+	return b
+}
+
+// Build returns the rule. All conditions must hold for resources to relate.
+func (b *RuleBuilder) Build() RelationshipRule {
+	return RelationshipRule{
+		Name:      b.name,
+		Reference: b.reference,
+		Cel:       strings.Join(b.conditions, " &&\n"),
+		Metadata:  map[string]string{},
+	}
+}
@@ -0,0 +1,53 @@
+package resourceprovider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourceBuilder(t *testing.T) {
+	resource, err := NewResource("db-1", "orders", "Database", "ctrlplane.dev/database/v1").
+		Config("engine", "postgres").
+		Database(DatabaseMetadata{Type: "postgres", Host: "orders.internal", Port: 5432, Version: "15.4"}).
+		Link("Console", "https://console.example.com/db-1").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"database/host":          "orders.internal",
+		"database/port":          "5432",
+		"database/version":       "15.4",
+		"database/version/major": "15",
+		"database/version/minor": "4",
+		"ctrlplane/links":        `{"Console":"https://console.example.com/db-1"}`,
+	} {
+		if got := resource.Metadata[key]; got != want {
+			t.Errorf("metadata %s = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := resource.Metadata["database/region"]; ok {
+		t.Error("expected empty metadata values to be skipped")
+	}
+
+	if _, err := NewResource("", "x", "", "v1").Build(); err == nil || !strings.Contains(err.Error(), "identifier, kind") {
+		t.Fatalf("expected missing fields to be reported, got %v", err)
+	}
+}
+
+func TestRuleBuilder(t *testing.T) {
+	rule := NewRelationshipRule("RDS Network", "network").
+		From("AmazonRelationalDatabaseService", "ctrlplane.dev/database/v1").
+		To("AmazonNetwork", "").
+		MatchMetadata("network/vpc", "network/id").
+		Build()
+
+	want := `from.kind == "AmazonRelationalDatabaseService" &&
+from.version == "ctrlplane.dev/database/v1" &&
+to.kind == "AmazonNetwork" &&
+from.metadata["network/vpc"] == to.metadata["network/id"]`
+	if rule.Cel != want {
+		t.Fatalf("unexpected CEL:\n%s", rule.Cel)
+	}
+}
@@ -2,12 +2,15 @@ package resourceprovider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 
 	"github.com/charmbracelet/log"
 	"github.com/ctrlplanedev/cli/internal/api"
+	"github.com/google/uuid"
 )
 
 func New(client *api.ClientWithResponses, workspace string, name string) (*ResourceProvider, error) {
@@ -77,24 +80,73 @@ func (r *ResourceProvider) UpsertResource(ctx context.Context, resources []api.R
 		body, _ := io.ReadAll(upsertResp.Body)
 		return upsertResp, fmt.Errorf("failed to upsert resources (HTTP %d): %s", upsertResp.StatusCode, string(body))
 	}
+	WriteSyncReport(r.Name, len(resources))
 	return upsertResp, nil
 }
 
-// func (r *ResourceProvider) AddResourceRelationshipRule(ctx context.Context, rules []api.ResourceProviderResourceRelationshipRule) error {
-// 	for _, rule := range rules {
-// 		rule.WorkspaceId = r.workspaceId
-// 		resp, err := r.client.CreateResourceRelationshipRuleWithResponse(ctx, rule)
-// 		if resp.StatusCode() == http.StatusConflict {
-// 			log.Info("Resource relationship rule already exists, skipped creation")
-// 			return nil
-// 		}
-// 		if err != nil {
-// 			return err
-// 		}
-// 		if resp.StatusCode() != http.StatusOK {
-// 			return fmt.Errorf("failed to upsert resource relationship rule: %s", string(resp.Body))
-// 		}
-// 	}
-// 	log.Info("Successfully created resource relationship rules")
-// 	return nil
-// }
+// SyncReportEnv names an environment variable holding a file path. When set,
+// every successful upsert appends a JSON line with the provider name and
+// resource count to that file. `ctrlc sync daemon` uses it to export resource
+// counts for the jobs it runs.
+const SyncReportEnv = "CTRLC_SYNC_REPORT_FILE"
+
+// SyncReport is a single line written to the SyncReportEnv file.
+type SyncReport struct {
+	Provider  string `json:"provider"`
+	Resources int    `json:"resources"`
+}
+
+// WriteSyncReport records a completed sync in the SyncReportEnv file, if one
+// is configured. UpsertResource calls it; syncs that skip the upsert because
+// nothing changed call it directly so their resource count is still reported.
+func WriteSyncReport(provider string, count int) {
+	path := os.Getenv(SyncReportEnv)
+	if path == "" {
+		return
+	}
+
+	line, err := json.Marshal(SyncReport{Provider: provider, Resources: count})
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Debug("Failed to open sync report file", "path", path, "error", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// relationshipRuleNamespace scopes the deterministic IDs generated for
+// relationship rules declared by sync integrations.
+var relationshipRuleNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://ctrlplane.dev/relationship-rules"))
+
+// RelationshipRuleID returns a stable ID for a rule so repeated syncs update
+// the same rule instead of creating duplicates.
+func RelationshipRuleID(workspaceId string, name string) string {
+	return uuid.NewSHA1(relationshipRuleNamespace, []byte(workspaceId+"/"+name)).String()
+}
+
+// UpsertRelationshipRules creates or updates the given relationship rules in
+// the provider's workspace. Rules are keyed by name.
+func (r *ResourceProvider) UpsertRelationshipRules(ctx context.Context, rules []api.UpsertRelationshipRuleRequest) error {
+	for _, rule := range rules {
+		if rule.Metadata == nil {
+			rule.Metadata = map[string]string{}
+		}
+
+		id := RelationshipRuleID(r.workspaceId, rule.Name)
+		resp, err := r.client.RequestRelationshipRuleUpsertWithResponse(ctx, r.workspaceId, id, rule)
+		if err != nil {
+			return fmt.Errorf("failed to upsert relationship rule %q: %w", rule.Name, err)
+		}
+		if resp.StatusCode() >= 400 {
+			return fmt.Errorf("failed to upsert relationship rule %q (HTTP %d): %s", rule.Name, resp.StatusCode(), string(resp.Body))
+		}
+		log.Debug("Upserted relationship rule", "name", rule.Name, "reference", rule.Reference, "id", id)
+	}
+	log.Info("Upserted relationship rules", "count", len(rules))
+	return nil
+}
@@ -0,0 +1,290 @@
+package resourceprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/syncstate"
+)
+
+// SyncOption configures optional behavior of Sync.
+type SyncOption func(*syncOptions)
+
+type syncOptions struct {
+	variables      map[string]map[string]any
+	rules          []RelationshipRule
+	stateDir       string
+	force          bool
+	grace          syncstate.GracePolicy
+	conflictPolicy ConflictPolicy
+}
+
+// WithVariables sets resource variables, keyed by resource identifier. They
+// are pushed after the resources are upserted, for resources that are new or
+// changed since the last sync.
+func WithVariables(variables map[string]map[string]any) SyncOption {
+	return func(o *syncOptions) {
+		if o.variables == nil {
+			o.variables = make(map[string]map[string]any, len(variables))
+		}
+		maps.Copy(o.variables, variables)
+	}
+}
+
+// WithRelationshipRules upserts the given relationship rules after the
+// resources.
+func WithRelationshipRules(rules ...RelationshipRule) SyncOption {
+	return func(o *syncOptions) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// WithStateDir stores the state used to detect unchanged resources in dir
+// instead of the user cache directory.
+func WithStateDir(dir string) SyncOption {
+	return func(o *syncOptions) {
+		if dir != "" {
+			o.stateDir = dir
+		}
+	}
+}
+
+// WithForce sends resources and variables even when nothing changed since
+// the last sync.
+func WithForce(force bool) SyncOption {
+	return func(o *syncOptions) {
+		o.force = force
+	}
+}
+
+// WithGracePeriod keeps resources that disappear from a sync, marked with
+// the ctrlplane/missing-since metadata, for the given duration and number of
+// runs. When both are set a resource is removed once it has exceeded both.
+func WithGracePeriod(period time.Duration, runs int) SyncOption {
+	return func(o *syncOptions) {
+		o.grace = syncstate.GracePolicy{Period: period, Runs: runs}
+	}
+}
+
+// WithConflictPolicy decides what happens to identifiers already owned by
+// another provider. The default is ConflictTakeOver.
+func WithConflictPolicy(policy ConflictPolicy) SyncOption {
+	return func(o *syncOptions) {
+		o.conflictPolicy = policy
+	}
+}
+
+// Change actions reported in a SyncResult.
+const (
+	ChangeAdded   = "added"
+	ChangeChanged = "changed"
+	ChangeRemoved = "removed"
+)
+
+// Change is one resource that was added, changed or removed by a sync.
+type Change struct {
+	Action     string `json:"action"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+// SyncResult describes what a sync did.
+type SyncResult struct {
+	// Unchanged is set when the resources matched the last sync and nothing
+	// was sent.
+	Unchanged bool
+	// FirstSync is set when there was no state from an earlier sync, in
+	// which case every resource is reported as added.
+	FirstSync bool
+	// Changes lists the resources added, changed and removed since the last
+	// sync.
+	Changes []Change
+	// Sent is the number of resources upserted.
+	Sent int
+	// Response is the decoded body of the upsert response, nil when nothing
+	// was sent.
+	Response map[string]any
+}
+
+// Sync replaces the provider's resources with the given set and uploads
+// their variables and relationship rules. The set is compared with the last
+// successful sync, kept in a local state directory, so unchanged syncs send
+// nothing and only new or changed resources have their variables uploaded.
+func (r *ResourceProvider) Sync(ctx context.Context, resources []Resource, opts ...SyncOption) (*SyncResult, error) {
+	options := syncOptions{stateDir: syncstate.DefaultDir(), conflictPolicy: ConflictTakeOver}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	variables := options.variables
+
+	store := syncstate.Store{Dir: options.stateDir}
+	previous, err := store.Load(r.workspaceId, r.ID)
+	if err != nil {
+		log.Warn("Ignoring unreadable sync state", "provider", r.Name, "error", err)
+		previous = nil
+	}
+
+	// With a grace period, resources missing from this sync are sent again,
+	// marked with ctrlplane/missing-since, until the period runs out.
+	var snapshots map[string]syncstate.Snapshot
+	if options.grace.Enabled() {
+		retained := syncstate.Retain(previous, resources, variables, options.grace, time.Now())
+		for _, identifier := range retained.Missing {
+			log.Warn("Keeping missing resource during grace period", "provider", r.Name, "identifier", identifier,
+				"missing_since", retained.Snapshots[identifier].MissingSince, "missed_runs", retained.Snapshots[identifier].MissedRuns)
+		}
+		for _, identifier := range retained.Expired {
+			log.Info("Removing resource after grace period", "provider", r.Name, "identifier", identifier)
+		}
+		resources, variables, snapshots = retained.Resources, retained.Variables, retained.Snapshots
+	}
+
+	current, err := syncstate.Compute(resources, variables)
+	if err != nil {
+		return nil, err
+	}
+	current.Snapshots = snapshots
+
+	result := &SyncResult{FirstSync: previous == nil}
+
+	if previous != nil && previous.Hash == current.Hash && !options.force {
+		log.Info("Resources unchanged since last sync, skipping upsert", "provider", r.Name, "count", len(resources), "last_sync", previous.UpdatedAt)
+		WriteSyncReport(r.Name, len(resources))
+		// Missed runs still count towards the grace period.
+		if !maps.EqualFunc(previous.Snapshots, current.Snapshots, func(a, b syncstate.Snapshot) bool { return a.MissedRuns == b.MissedRuns }) {
+			current.UpdatedAt = previous.UpdatedAt
+			if err := store.Save(r.workspaceId, r.ID, current); err != nil {
+				log.Warn("Failed to save sync state", "provider", r.Name, "error", err)
+			}
+		}
+		result.Unchanged = true
+		return result, r.upsertRules(ctx, options.rules)
+	}
+
+	owned, err := r.CheckConflicts(ctx, resources, options.conflictPolicy)
+	if err != nil {
+		return nil, err
+	}
+	// Resources skipped for a conflict are left out of the saved state and
+	// the reported changes, so the next sync retries them.
+	if len(owned) < len(resources) {
+		kept := make(map[string]bool, len(owned))
+		for _, resource := range owned {
+			kept[resource.Identifier] = true
+		}
+		variables = maps.Clone(variables)
+		maps.DeleteFunc(variables, func(identifier string, _ map[string]any) bool { return !kept[identifier] })
+		snapshots = maps.Clone(snapshots)
+		maps.DeleteFunc(snapshots, func(identifier string, _ syncstate.Snapshot) bool { return !kept[identifier] })
+
+		if current, err = syncstate.Compute(owned, variables); err != nil {
+			return nil, err
+		}
+		current.Snapshots = snapshots
+	}
+
+	added, changed, removed := syncstate.Diff(previous, current)
+	log.Debug("Resource changes since last sync", "added", len(added), "changed", len(changed), "removed", len(removed))
+
+	response, err := r.upsertWithRetry(ctx, owned)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Successfully upserted resources", "provider", r.Name, "count", len(owned))
+	result.Sent = len(owned)
+	result.Response = response
+
+	// Unchanged resources already carry their variables, unless a forced
+	// sync asks for everything to be sent again.
+	pending := make(map[string]map[string]any, len(variables))
+	for _, identifier := range append(added, changed...) {
+		if vars, ok := variables[identifier]; ok {
+			pending[identifier] = vars
+		}
+	}
+	if options.force {
+		pending = variables
+	}
+	if len(pending) > 0 {
+		if err := r.UpdateVariables(ctx, pending); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := store.Save(r.workspaceId, r.ID, current); err != nil {
+		log.Warn("Failed to save sync state", "provider", r.Name, "error", err)
+	}
+
+	byIdentifier := make(map[string]Resource, len(owned))
+	for _, resource := range owned {
+		byIdentifier[resource.Identifier] = resource
+	}
+	for _, identifier := range added {
+		resource := byIdentifier[identifier]
+		result.Changes = append(result.Changes, Change{Action: ChangeAdded, Identifier: identifier, Name: resource.Name, Kind: resource.Kind})
+	}
+	for _, identifier := range changed {
+		resource := byIdentifier[identifier]
+		result.Changes = append(result.Changes, Change{Action: ChangeChanged, Identifier: identifier, Name: resource.Name, Kind: resource.Kind})
+	}
+	for _, identifier := range removed {
+		result.Changes = append(result.Changes, Change{Action: ChangeRemoved, Identifier: identifier, Kind: previous.Kinds[identifier]})
+	}
+
+	return result, r.upsertRules(ctx, options.rules)
+}
+
+func (r *ResourceProvider) upsertRules(ctx context.Context, rules []RelationshipRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return r.UpsertRelationshipRules(ctx, rules)
+}
+
+// upsertWithRetry sends the resource set, retrying network errors, rate
+// limiting and server errors with backoff. It returns the decoded response
+// body, or nil when it is not a JSON object.
+func (r *ResourceProvider) upsertWithRetry(ctx context.Context, resources []Resource) (map[string]any, error) {
+	var response map[string]any
+	err := retry.Do(
+		func() error {
+			resp, err := r.UpsertResource(ctx, resources)
+			if err == nil {
+				defer resp.Body.Close()
+				if json.NewDecoder(resp.Body).Decode(&response) != nil {
+					response = nil
+				}
+				return nil
+			}
+			if resp != nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return retry.Unrecoverable(err)
+			}
+			log.Debug("Retrying resource upsert", "provider", r.Name, "error", err)
+			return err
+		},
+		retry.Context(ctx),
+		retry.Attempts(5),
+		retry.Delay(time.Second),
+		retry.MaxDelay(30*time.Second),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+	)
+	return response, err
+}
+
+// Sync is a convenience for programs that sync a single provider: it creates
+// or finds the provider by name and syncs the resources to it.
+func Sync(ctx context.Context, client *Client, workspace string, name string, resources []Resource, opts ...SyncOption) (*SyncResult, error) {
+	rp, err := New(client, workspace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource provider: %w", err)
+	}
+	return rp.Sync(ctx, resources, opts...)
+}
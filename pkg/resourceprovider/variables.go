@@ -0,0 +1,70 @@
+package resourceprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/charmbracelet/log"
+	"github.com/ctrlplanedev/cli/internal/api"
+)
+
+// UpdateResourceVariables replaces the variables of the resource with the
+// given identifier. Resources set through a provider are created
+// asynchronously, so a 404 is retried with backoff until the resource exists.
+func UpdateResourceVariables(ctx context.Context, client *api.ClientWithResponses, workspaceId string, identifier string, variables map[string]any) error {
+	if variables == nil {
+		variables = map[string]any{}
+	}
+
+	return retry.Do(
+		func() error {
+			varsResp, err := client.RequestResourceVariablesUpdateWithResponse(
+				ctx,
+				workspaceId,
+				identifier,
+				api.RequestResourceVariablesUpdateJSONRequestBody(variables),
+			)
+			if err != nil {
+				return retry.Unrecoverable(fmt.Errorf("failed to update resource variables for '%s': %w", identifier, err))
+			}
+			if varsResp == nil {
+				return retry.Unrecoverable(fmt.Errorf("failed to update resource variables for '%s': empty response", identifier))
+			}
+			if varsResp.StatusCode() == 404 {
+				return fmt.Errorf("resource '%s' not found yet, retrying", identifier)
+			}
+			if varsResp.StatusCode() != 202 {
+				return retry.Unrecoverable(
+					fmt.Errorf("failed to update resource variables for '%s': %s", identifier, string(varsResp.Body)),
+				)
+			}
+			return nil
+		},
+		retry.Context(ctx),
+		retry.Attempts(10),
+		retry.Delay(100*time.Millisecond),
+		retry.MaxDelay(15*time.Second),
+		retry.DelayType(retry.BackOffDelay),
+	)
+}
+
+// UpdateVariables replaces the variables of each resource in the map, keyed
+// by resource identifier.
+func (r *ResourceProvider) UpdateVariables(ctx context.Context, variables map[string]map[string]any) error {
+	identifiers := make([]string, 0, len(variables))
+	for identifier := range variables {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	for _, identifier := range identifiers {
+		if err := UpdateResourceVariables(ctx, r.client, r.workspaceId, identifier, variables[identifier]); err != nil {
+			return err
+		}
+	}
+	log.Info("Updated resource variables", "provider", r.Name, "resources", len(identifiers))
+	return nil
+}